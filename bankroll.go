@@ -0,0 +1,165 @@
+package douji
+
+import "sort"
+
+type sidePot struct {
+	amount  int
+	players []*Player
+	shares  map[string]int // the points each player put into the pot, by player id.
+}
+
+// take moves amount points from p into the pot. A player who can't cover it goes all-in with whatever they have left.
+func (g *Game) take(p *Player, amount int) {
+	paid := amount
+	if paid > p.points {
+		paid = p.points
+	}
+	if paid < 0 {
+		paid = 0
+	}
+	p.points -= paid
+	g.pot += paid
+	if g.contributions == nil {
+		g.contributions = map[string]int{}
+	}
+	g.contributions[p.id] += paid
+	if amount > 0 && p.points <= 0 {
+		g.goAllIn(p, paid)
+	}
+}
+
+// goAllIn marks p as having put all their points into the pot, the last paid of them.
+func (g *Game) goAllIn(p *Player, paid int) {
+	if g.allIn == nil {
+		g.allIn = map[string]bool{}
+	}
+	g.allIn[p.id] = true
+	g.emit(AllIn{GameId: g.id, Round: g.round, PlayerId: p.id, Points: paid})
+}
+
+// canCall reports whether a player still has points to call with, i.e. hasn't gone all-in this game.
+func (g *Game) canCall(p *Player) bool {
+	return !g.allIn[p.id]
+}
+
+// callers returns how many players in the game can still call; with fewer than two there's nobody to call against.
+func (g *Game) callers() int {
+	n := 0
+	for _, p := range g.players {
+		if g.canCall(p) {
+			n++
+		}
+	}
+	return n
+}
+
+// sidePots splits the pot by how much each player still in the game put in. The main pot holds what every one of them matched
+// plus any bombed pot carried into the game; every further side pot holds what the players who put in more matched between them.
+// Points folded players put in beyond what anyone still in matched go to the last side pot.
+func (g *Game) sidePots() []sidePot {
+	var levels []int
+	for _, p := range g.players {
+		levels = append(levels, g.contributions[p.id])
+	}
+	sort.Ints(levels)
+	var pots []sidePot
+	prev := 0
+	for _, level := range levels {
+		if level == prev && len(pots) > 0 {
+			continue
+		}
+		pot := sidePot{shares: map[string]int{}}
+		for _, p := range g.seated {
+			if share := clamp(g.contributions[p.id], prev, level) - prev; share > 0 {
+				pot.amount += share
+				pot.shares[p.id] += share
+			}
+		}
+		for _, p := range g.players {
+			if g.contributions[p.id] >= level {
+				pot.players = append(pot.players, p)
+			}
+		}
+		pots = append(pots, pot)
+		prev = level
+	}
+	for _, p := range g.seated {
+		if c := g.contributions[p.id]; c > prev {
+			pots[len(pots)-1].amount += c - prev
+			pots[len(pots)-1].shares[p.id] += c - prev
+		}
+	}
+	pots[0].amount += g.carried
+	for id, share := range g.carriedShares {
+		pots[0].shares[id] += share
+	}
+	return pots
+}
+
+func clamp(v, min, max int) int {
+	switch {
+	case v < min:
+		return min
+	case v > max:
+		return max
+	}
+	return v
+}
+
+// settle decides a pot between the players who can win it and pays the winner, returning nil when the pot carries over.
+// A single four a kind wins regardless of scores, otherwise the largest final score wins and a tie is broken by the tie policy.
+func (g *Game) settle(pot sidePot) *Player {
+	players := pot.players
+	if len(players) == 1 {
+		g.pay(players[0], pot.amount)
+		return players[0]
+	}
+	// check for four a kind!
+	if fkp, ok := checkFourKind(players); ok {
+		g.emit(FourKindWin{GameId: g.id, PlayerId: fkp.id, Pot: pot.amount})
+		g.pay(fkp, pot.amount)
+		return fkp
+	}
+	// no single four kind, check for final score to determine a winner.
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].FinalScore() > players[j].FinalScore()
+	})
+	// check for a tie.
+	if players[0].FinalScore() == players[1].FinalScore() {
+		return g.breakTie(pot)
+	}
+	g.pay(players[0], pot.amount)
+	return players[0]
+}
+
+// showdown settles every side pot in the final round. It returns the winner of the main pot, nil when it carries over,
+// and the points of every pot carried over to the next game.
+func (g *Game) showdown() (*Player, int) {
+	pots := g.sidePots()
+	if len(pots) > 1 {
+		split := PotSplit{GameId: g.id}
+		for _, pot := range pots {
+			split.Pots = append(split.Pots, SidePot{Pot: pot.amount, PlayerIds: playerIds(pot.players)})
+		}
+		g.emit(split)
+	}
+	var winner *Player
+	for i, pot := range pots {
+		w := g.settle(pot)
+		if i == 0 {
+			winner = w
+		}
+	}
+	return winner, g.pot
+}
+
+// playersWithPoints returns the players who can afford to play another game.
+func playersWithPoints(players []*Player) []*Player {
+	var ps []*Player
+	for _, p := range players {
+		if p.points > 0 {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
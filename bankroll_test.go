@@ -0,0 +1,125 @@
+package douji
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAllInSidePot(t *testing.T) {
+	a, b, c := NewTestPlayer("a", "a", 2), NewTestPlayer("b", "b", 50), NewTestPlayer("c", "c", 50)
+	deck := &Deck{[]Card{
+		{15, "♠"}, {9, "♠"}, {4, "♠"}, // hidden cards.
+		{3, "♠"}, {13, "♠"}, {5, "♠"}, // b has the largest face score and calls every round.
+		{13, "♣"}, {6, "♠"}, {15, "♣"}, // dealt from b in asking order: b, c, a.
+		{11, "♠"}, {7, "♠"}, {15, "♦"},
+		{10, "♠"}, {8, "♣"}, {12, "♠"},
+	}}
+	log := &EventLog{}
+	g := NewGame(0, []*Player{a, b, c}, 1, 1, 0, 1, 5, nil, DefaultRules())
+	g.SetEventSink(log)
+	winner, pot, _ := g.run(false, alwaysInMiddleGame{}, deck)
+
+	if winner != a || pot != 0 {
+		t.Errorf("expected a to win the main pot with nothing bombed but got:%v, pot:%d", winner, pot)
+	}
+	if a.points != 6 || b.points != 51 || c.points != 45 {
+		t.Errorf("expected a to win 6 from the main pot and b 6 from the side pot but got a:%d, b:%d, c:%d", a.points, b.points, c.points)
+	}
+	var allIns []AllIn
+	var split PotSplit
+	for _, e := range log.Events() {
+		switch ev := e.(type) {
+		case AllIn:
+			allIns = append(allIns, ev)
+		case PotSplit:
+			split = ev
+		case InOrOutDecided:
+			if ev.PlayerId == "a" && ev.Round > 1 {
+				t.Errorf("expected an all-in player to not be asked again but got:%+v", ev)
+			}
+		case Called:
+			if ev.PlayerId == "a" {
+				t.Errorf("expected an all-in player to never call but got:%+v", ev)
+			}
+		}
+	}
+	if want := []AllIn{{GameId: 0, Round: 1, PlayerId: "a", Points: 1}}; !reflect.DeepEqual(allIns, want) {
+		t.Errorf("expected a to go all-in in the first round but got:%+v", allIns)
+	}
+	want := PotSplit{GameId: 0, Pots: []SidePot{{Pot: 6, PlayerIds: []string{"b", "c", "a"}}, {Pot: 6, PlayerIds: []string{"b", "c"}}}}
+	if !reflect.DeepEqual(split, want) {
+		t.Errorf("expected a main pot of 6 and a side pot of 6 but got:%+v", split)
+	}
+}
+
+func TestAllInCannotCoverBase(t *testing.T) {
+	a, b := NewTestPlayer("a", "a", 0), NewTestPlayer("b", "b", 10)
+	g := NewGame(0, []*Player{a, b}, 1, 1, 0, 1, 5, nil, DefaultRules())
+	g.start(NewSeededDeck(1))
+	if a.points != 0 || g.pot != 1 || !g.view(b).Seats()[0].AllIn {
+		t.Errorf("expected a player without points to go all-in with nothing but got points:%d, pot:%d", a.points, g.pot)
+	}
+}
+
+func TestSetRunExcludesBrokePlayers(t *testing.T) {
+	players := getFourTestingPlayers()
+	players[1].points = 0
+	log := &EventLog{}
+	s := newTestSet(3, DefaultRules())
+	s.SetSeed(3)
+	s.SetEventSink(log)
+	s.Run(players, alwaysInMiddleGame{}, &recordingDb{}, 1, 1, 0)
+	games := 0
+	for _, e := range log.Events() {
+		if started, ok := e.(GameStarted); ok {
+			games++
+			for _, p := range started.Players {
+				if p.Id == players[1].id {
+					t.Errorf("expected a player without points to sit out game %d.", started.GameId)
+				}
+			}
+		}
+	}
+	if games < 3 {
+		t.Errorf("expected at least 3 games but got:%d", games)
+	}
+
+	log = &EventLog{}
+	s.SetEventSink(log)
+	s.Run([]*Player{NewTestPlayer("a", "a", 0), NewTestPlayer("b", "b", 0), NewTestPlayer("c", "c", 10)}, alwaysInMiddleGame{}, &recordingDb{}, 1, 1, 0)
+	if n := len(log.Events()); n != 0 {
+		t.Errorf("expected no game with a single player who has points but got %d events.", n)
+	}
+}
+
+func TestPrevWinnerSittingOutDoesNotCallFirst(t *testing.T) {
+	players := []*Player{NewTestPlayer("A", "A", 3), NewTestPlayer("B", "B", 100), NewTestPlayer("C", "C", 100)}
+	log := &EventLog{}
+	s := newTestSet(3, DefaultRules())
+	s.SetSeed(37)
+	s.SetEventSink(log)
+	if err := s.Run(players, alwaysInMiddleGame{}, &recordingDb{}, 1, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	sittingOut := false
+	for _, e := range log.Events() {
+		if started, ok := e.(GameStarted); ok && len(started.Players) < len(players) {
+			sittingOut = true
+			if started.PrevWinnerId != "" && !hasPlayerDTO(started.Players, started.PrevWinnerId) {
+				t.Errorf("expected no previous winner sitting out game %d to call first but got:%s", started.GameId, started.PrevWinnerId)
+			}
+		}
+	}
+	if !sittingOut {
+		t.Errorf("expected the previous winner to sit out a carried pot they have no share of.")
+	}
+}
+
+func hasPlayerDTO(players []PlayerDTO, id string) bool {
+	for _, p := range players {
+		if p.Id == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package douji
+
+import "sync"
+
+// PotStats is what's saved about a game's pot besides every player's points.
+type PotStats struct {
+	Carried      int      `json:"carried"`      // points carried over to the next game, 0 when the pot was won.
+	Bombs        int      `json:"bombs"`        // pots in a row carried over, this game's included.
+	Contributors []string `json:"contributors"` // ids of the players who put points into the carried pot.
+	Rejoining    []string `json:"rejoining"`    // ids of the contributors who may play the next game for it.
+	ForcedSplit  bool     `json:"forced_split"` // a tie was split because MaxBombs pots in a row were carried over.
+}
+
+// carriedPot is a pot a game carries over to the next one, bombed or waiting for a tie-break.
+type carriedPot struct {
+	pot         int
+	bombs       int
+	shares      map[string]int // points each player put into the pot, by player id.
+	showdown    []*Player      // the players in the final comparison of the pot.
+	tieBreakers []*Player
+}
+
+// carryOver returns the pot the game leaves for the next one, nil when every pot was won.
+func (g *Game) carryOver(pot int) *carriedPot {
+	if pot == 0 {
+		return nil
+	}
+	return &carriedPot{pot: pot, bombs: g.bombs + 1, shares: g.bombedShares, showdown: g.bombedPlayers, tieBreakers: g.tieBreakers}
+}
+
+// players returns who of everyone in the set plays the next game for the pot, in seating order, and the ids of the newcomers among them.
+// Players who put points into the pot play by the rejoin rule, even when they have no points left to call with, and everyone else by the newcomer rule.
+func (c *carriedPot) players(rules RuleSet, all []*Player) ([]*Player, []string) {
+	var playing []*Player
+	var newcomers []string
+	for _, p := range all {
+		switch {
+		case c.tieBreakers != nil:
+			if containsPlayer(c.tieBreakers, p) {
+				playing = append(playing, p) // only the tied players play a tie-break game.
+			}
+		case c.shares[p.id] > 0:
+			if rules.Rejoin == RejoinContributors || containsPlayer(c.showdown, p) {
+				playing = append(playing, p)
+			}
+		case p.points > 0 && rules.Newcomers != NewcomersWait:
+			playing = append(playing, p)
+			newcomers = append(newcomers, p.id)
+		}
+	}
+	return playing, newcomers
+}
+
+// buyIn returns the points a newcomer puts in to play for the pot: the most any player put into it.
+func (c *carriedPot) buyIn(rules RuleSet) int {
+	if rules.Newcomers != NewcomersBuyIn || c.tieBreakers != nil {
+		return 0
+	}
+	most := 0
+	for _, share := range c.shares {
+		if share > most {
+			most = share
+		}
+	}
+	return most
+}
+
+// stats returns the saved stats of the pot.
+func (c *carriedPot) stats(rules RuleSet, all []*Player) PotStats {
+	if c == nil {
+		return PotStats{}
+	}
+	stats := PotStats{Carried: c.pot, Bombs: c.bombs}
+	playing, _ := c.players(rules, all)
+	for _, p := range all {
+		if c.shares[p.id] > 0 {
+			stats.Contributors = append(stats.Contributors, p.id)
+			if containsPlayer(playing, p) {
+				stats.Rejoining = append(stats.Rejoining, p.id)
+			}
+		}
+	}
+	return stats
+}
+
+// joinQueue holds the players waiting to join a running set.
+type joinQueue struct {
+	mu      sync.Mutex
+	players []*Player
+}
+
+// Join seats p in the set from its next game on. It can be called while the set is running.
+func (s *Set) Join(p *Player) {
+	if s.joins == nil {
+		s.joins = &joinQueue{}
+	}
+	s.joins.mu.Lock()
+	defer s.joins.mu.Unlock()
+	s.joins.players = append(s.joins.players, p)
+}
+
+// joined returns the players who joined since it was last called.
+func (s Set) joined() []*Player {
+	if s.joins == nil {
+		return nil
+	}
+	s.joins.mu.Lock()
+	defer s.joins.mu.Unlock()
+	players := s.joins.players
+	s.joins.players = nil
+	return players
+}
+
+func contains(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
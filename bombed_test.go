@@ -0,0 +1,135 @@
+package douji
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCarriedPotPlayers(t *testing.T) {
+	a, b, c, d := NewTestPlayer("a", "a", 0), NewTestPlayer("b", "b", 10), NewTestPlayer("c", "c", 10), NewTestPlayer("d", "d", 10)
+	all := []*Player{a, b, c, d}
+	pot := &carriedPot{pot: 9, bombs: 1, shares: map[string]int{"a": 2, "b": 4, "c": 3}, showdown: []*Player{a, b}}
+	for _, tc := range []struct {
+		rejoin    RejoinRule
+		newcomers NewcomerRule
+		playing   []string
+		newIds    []string
+		buyIn     int
+	}{
+		{RejoinContributors, NewcomersWait, []string{"a", "b", "c"}, nil, 0},
+		{RejoinShowdown, NewcomersWait, []string{"a", "b"}, nil, 0},
+		{RejoinShowdown, NewcomersBuyIn, []string{"a", "b", "d"}, []string{"d"}, 4},
+		{RejoinContributors, NewcomersFree, []string{"a", "b", "c", "d"}, []string{"d"}, 0},
+	} {
+		rules := DefaultRules()
+		rules.Rejoin, rules.Newcomers = tc.rejoin, tc.newcomers
+		playing, newcomers := pot.players(rules, all)
+		if ids := playerIds(playing); !reflect.DeepEqual(ids, tc.playing) || !reflect.DeepEqual(newcomers, tc.newIds) {
+			t.Errorf("rejoin %d, newcomers %d: expected %v to play with newcomers %v but got %v and %v", tc.rejoin, tc.newcomers, tc.playing, tc.newIds, ids, newcomers)
+		}
+		if got := pot.buyIn(rules); got != tc.buyIn {
+			t.Errorf("rejoin %d, newcomers %d: expected a buy in of %d but got:%d", tc.rejoin, tc.newcomers, tc.buyIn, got)
+		}
+	}
+
+	rules := DefaultRules()
+	rules.Rejoin = RejoinShowdown
+	want := PotStats{Carried: 9, Bombs: 1, Contributors: []string{"a", "b", "c"}, Rejoining: []string{"a", "b"}}
+	if got := pot.stats(rules, all); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected stats %+v but got:%+v", want, got)
+	}
+}
+
+func TestNewcomerBuysIn(t *testing.T) {
+	a, b, c := NewTestPlayer("a", "a", 10), NewTestPlayer("b", "b", 10), NewTestPlayer("c", "c", 10)
+	g := NewGame(0, []*Player{a, b, c}, 1, 1, 6, 1, 5, nil, DefaultRules())
+	g.buyIn, g.newcomers = 3, []string{"c"}
+	g.start(NewSeededDeck(1))
+	if a.points != 10 || b.points != 10 || c.points != 7 || g.pot != 9 {
+		t.Errorf("expected only the newcomer to put 3 into the carried pot but got a:%d, b:%d, c:%d, pot:%d", a.points, b.points, c.points, g.pot)
+	}
+}
+
+func TestForcedSplitAfterMaxBombs(t *testing.T) {
+	rules := DefaultRules()
+	rules.OneHiddenRounds, rules.MaxBombs = 1, 1
+	a, b := NewTestPlayer("a", "a", 10), NewTestPlayer("b", "b", 10)
+	log := &EventLog{}
+	g := NewGame(0, []*Player{a, b}, 1, 1, 6, 1, 5, nil, rules)
+	g.bombs = 1
+	g.SetEventSink(log)
+	_, pot, _ := g.run(false, alwaysInMiddleGame{}, &Deck{[]Card{{5, "♠"}, {4, "♣"}, {6, "♠"}, {7, "♣"}}})
+
+	if pot != 0 || !g.forcedSplit || a.points != 13 || b.points != 13 {
+		t.Errorf("expected the tie to split a pot of 8 after one bomb but got pot:%d, a:%d, b:%d", pot, a.points, b.points)
+	}
+	for _, e := range log.Events() {
+		if shared, ok := e.(PotShared); ok && !shared.Forced {
+			t.Errorf("expected the split to be recorded as forced.")
+		}
+	}
+}
+
+func TestSetRunCarriesBombedPots(t *testing.T) {
+	for _, rejoin := range []RejoinRule{RejoinContributors, RejoinShowdown} {
+		rules := DefaultRules()
+		rules.Rejoin = rejoin
+		bombs := 0
+		for seed := int64(1); seed <= 10; seed++ {
+			players := getFourTestingPlayers()
+			d := NewDispatcher(ThresholdBot{Fold: 10, Raise: 20})
+			d.Seat("0", alwaysInMiddleGame{})
+			log := &EventLog{}
+			db := &recordingDb{}
+			s := newTestSet(10, rules)
+			s.SetSeed(seed)
+			s.SetEventSink(log)
+			s.Run(players, d, db, 1, 1, 0)
+
+			var bombed *PotBombed
+			for _, game := range SplitGames(log.Events()) {
+				started := game[0].(GameStarted)
+				if bombed != nil {
+					bombs++
+					stats := db.pots[started.GameId] // saved with the previous game's 1 based id.
+					ids := make([]string, len(started.Players))
+					for i, p := range started.Players {
+						ids[i] = p.Id
+					}
+					if !reflect.DeepEqual(ids, stats.Rejoining) {
+						t.Errorf("rejoin %d, seed %d: expected %v saved as rejoining to play but got:%v", rejoin, seed, stats.Rejoining, ids)
+					}
+					compared := append([]string(nil), bombed.PlayerIds...)
+					sort.Strings(compared) // the final comparison is sorted by score, test ids are in seating order.
+					if rejoin == RejoinShowdown && !reflect.DeepEqual(ids, compared) {
+						t.Errorf("seed %d: expected only the players in the final comparison %v to play but got:%v", seed, compared, ids)
+					}
+					if stats.Bombs != started.Bombs || started.Bombs < 1 || stats.Carried != started.Pot {
+						t.Errorf("rejoin %d, seed %d: expected the saved stats %+v to match the carried pot of %+v", rejoin, seed, stats, started)
+					}
+				}
+				bombed = nil
+				if pb, ok := game[len(game)-1].(PotBombed); ok {
+					bombed = &pb
+				}
+			}
+		}
+		if bombs == 0 {
+			t.Errorf("rejoin %d: expected some bombed pots in 10 sets.", rejoin)
+		}
+	}
+}
+
+func TestSetJoin(t *testing.T) {
+	players := getFourTestingPlayers()
+	log := &EventLog{}
+	s := newTestSet(1, DefaultRules())
+	s.SetEventSink(log)
+	s.Join(NewTestPlayer("newcomer", "4", 100))
+	s.Run(players, alwaysInMiddleGame{}, &recordingDb{}, 1, 1, 0)
+	started := log.Events()[0].(GameStarted)
+	if len(started.Players) != 5 || started.Players[4].Id != "4" {
+		t.Errorf("expected a joined player to play the next game but got:%v", started.Players)
+	}
+}
@@ -0,0 +1,125 @@
+package douji
+
+import (
+	"math/rand"
+)
+
+// RandomBot calls a random point from the calls it can make and stays in half of the time.
+type RandomBot struct {
+	r *rand.Rand
+}
+
+// NewRandomBot creates a RandomBot whose decisions are reproducible by seed.
+func NewRandomBot(seed int64) *RandomBot {
+	return &RandomBot{r: rand.New(rand.NewSource(seed))}
+}
+
+func (b *RandomBot) Name() string { return "random" }
+
+func (b *RandomBot) CallOnce(view TableView, step, end int, lastCall bool) int {
+	options := view.CallOptions()
+	return options[b.r.Intn(len(options))]
+}
+
+func (b *RandomBot) InOrOut(view TableView, callingChip int) bool {
+	return b.r.Intn(2) == 1
+}
+
+// ThresholdBot compares its own score against the best public score of the other players still in the game.
+// It quits when behind by more than Fold points, makes the highest call it can when ahead by at least Raise points, and the lowest otherwise.
+// A bot short of points goes all-in instead.
+type ThresholdBot struct {
+	Fold  int
+	Raise int
+}
+
+func (b ThresholdBot) Name() string { return "threshold" }
+
+// lead returns how far the player's own score is ahead of the best opponent's public score.
+// A player with the largest face score is a calling player so the face score breaks ties in favour of the bot.
+func lead(view TableView) int {
+	best, bestFace := 0, 0
+	for _, op := range view.Opponents() {
+		if ps := op.PublicScore(); ps > best {
+			best = ps
+		}
+		if fs := op.FaceScore(); fs > bestFace {
+			bestFace = fs
+		}
+	}
+	l := view.Score() - best
+	if l == 0 && view.Self().FaceScore() >= bestFace {
+		l = 1
+	}
+	return l
+}
+
+func (b ThresholdBot) CallOnce(view TableView, step, end int, lastCall bool) int {
+	l := lead(view)
+	options := view.CallOptions()
+	switch {
+	case l < -b.Fold || len(options) < 2:
+		return 0
+	case l >= b.Raise:
+		return options[len(options)-1]
+	default:
+		return options[1]
+	}
+}
+
+func (b ThresholdBot) InOrOut(view TableView, callingChip int) bool {
+	return lead(view) >= -b.Fold
+}
+
+// PotOddsBot stays in whenever its chance of winning is at least the pot odds of the call,
+// and calls the amount with the best expected value assuming every opponent stays in.
+type PotOddsBot struct {
+	// Equity estimates the chance of winning the game; nil uses a quick estimate from the scores.
+	Equity func(view TableView) float64
+}
+
+func (b PotOddsBot) Name() string { return "potOdds" }
+
+func (b PotOddsBot) equity(view TableView) float64 {
+	if b.Equity != nil {
+		return b.Equity(view)
+	}
+	return quickEquity(view)
+}
+
+func (b PotOddsBot) CallOnce(view TableView, step, end int, lastCall bool) int {
+	eq := b.equity(view)
+	n := len(view.Opponents())
+	best, bestEV := 0, 0.0
+	for _, c := range view.CallOptions()[1:] {
+		// winning takes the pot, the call and every opponent's matching call; the call itself is the cost.
+		ev := eq*float64(view.Pot()+c*(n+1)) - float64(c)
+		if ev > bestEV {
+			best, bestEV = c, ev
+		}
+	}
+	return best
+}
+
+func (b PotOddsBot) InOrOut(view TableView, callingChip int) bool {
+	odds := float64(callingChip) / float64(view.Pot()+callingChip)
+	return b.equity(view) >= odds
+}
+
+// quickEquity guesses the chance of beating every opponent by assuming each hidden card scores about 9.
+func quickEquity(view TableView) float64 {
+	hidden := len(view.HiddenCards())
+	eq := 1.0
+	for _, op := range view.Opponents() {
+		guess := op.PublicScore() + 9*hidden
+		p := 0.5 + float64(view.Score()-guess)/40
+		if p < 0.05 {
+			p = 0.05
+		}
+		if p > 0.95 {
+			p = 0.95
+		}
+		eq *= p
+	}
+	return eq
+}
@@ -1,54 +1,194 @@
 package douji
 
 import (
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
 	"time"
 )
 
+// csvDb keeps game stats and players in two csv files, creating them when they're first written.
 type csvDb struct {
-	file string
-	// writer *csv.Writer
+	file        string // game stats: a row for every player after every game.
+	playersFile string // players with their ids and starting points.
+	mu          *sync.Mutex
 }
 
+var (
+	statsHeader   = []string{"set_id", "game_id", "player_name", "points", "saved_at", "seed", "carried_pot", "bombs", "contributed", "rejoining", "forced_split", "player_id"}
+	playersHeader = []string{"player_id", "player_name", "password_sha256", "points", "created_at"}
+)
+
+// NewCSV keeps the csv files in the working directory.
 func NewCSV() csvDb {
-	return csvDb{file: "douji.csv"}
+	return NewCSVIn(".")
+}
+
+// NewCSVIn keeps the csv files in dir.
+func NewCSVIn(dir string) csvDb {
+	return csvDb{file: filepath.Join(dir, "douji.csv"), playersFile: filepath.Join(dir, "douji_players.csv"), mu: &sync.Mutex{}}
 }
 
-func (c csvDb) SaveGameStats(setId string, gameId int, players []PlayerDTO) error {
-	file, err := os.OpenFile(c.file, os.O_WRONLY|os.O_APPEND, 0644)
+func (c csvDb) SaveGameStats(setId string, gameId int, seed int64, pot PotStats, players []PlayerDTO) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	saved, err := readRows(c.file, statsHeader)
 	if err != nil {
-		panic("cannot open csv file.")
+		return fmt.Errorf("saving stats of game %d: %w", gameId, err)
 	}
-	defer file.Close()
-	w := csv.NewWriter(file)
+	keys := map[string]bool{}
+	for _, row := range saved {
+		if len(row) == len(statsHeader) {
+			keys[statsRowKey(row)] = true
+		}
+	}
+	rows := make([][]string, 0, len(players))
 	for _, p := range players {
-		// d := fmt.Sprintf("%d,%d,%s,%d", setId, gameId, p.Name, p.points)
-		// sd := strings.Split(d, ",")
-		d := dataToWrite(setId, gameId, p.Name, p.Points)
-		fmt.Println(d)
-
-		if err := w.Write(d); err != nil {
-			panic(err)
+		if keys[StatsKey(setId, gameId, p.Id)] {
+			continue
 		}
+		d := dataToWrite(setId, gameId, seed, p.Name, p.Points)
+		d = append(d, potData(pot, p.Id)...)
+		rows = append(rows, append(d, p.Id))
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := appendRows(c.file, statsHeader, rows); err != nil {
+		return fmt.Errorf("saving stats of game %d: %w", gameId, err)
 	}
-	w.Flush()
 	return nil
 }
 
-func (csv csvDb) CreatePlayer(name, password string, points int) (string, error) {
-	return "", nil
+// CreatePlayer adds a player with a new id to the players file, only keeping a hash of the password.
+func (c csvDb) CreatePlayer(name, password string, points int) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	players, err := readRows(c.playersFile, playersHeader)
+	if err != nil {
+		return "", err
+	}
+	for _, row := range players {
+		if row[1] == name {
+			return "", fmt.Errorf("player %s already exists", name)
+		}
+	}
+	id := strconv.Itoa(len(players) + 1)
+	hash := sha256.Sum256([]byte(password))
+	row := []string{id, name, hex.EncodeToString(hash[:]), strconv.Itoa(points), time.Now().Local().String()}
+	if err := appendRows(c.playersFile, playersHeader, [][]string{row}); err != nil {
+		return "", fmt.Errorf("creating player %s: %w", name, err)
+	}
+	return id, nil
+}
+
+// LoadPlayerStatsByName loads a player with their points after the last game saved, their starting points if they haven't played yet.
+func (c csvDb) LoadPlayerStatsByName(name string) (*Player, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	players, err := readRows(c.playersFile, playersHeader)
+	if err != nil {
+		return nil, err
+	}
+	var p *Player
+	for _, row := range players {
+		if row[1] == name {
+			points, err := strconv.Atoi(row[3])
+			if err != nil {
+				return nil, fmt.Errorf("invalid points of player %s: %w", name, err)
+			}
+			p = &Player{Name: name, id: row[0], points: points}
+		}
+	}
+	if p == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPlayerNotFound, name)
+	}
+	stats, err := readRows(c.file, statsHeader)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range stats {
+		if row[2] == name {
+			points, err := strconv.Atoi(row[3])
+			if err != nil {
+				return nil, fmt.Errorf("invalid points of player %s: %w", name, err)
+			}
+			p.points = points // rows are appended in the order games are saved, the last one is the latest.
+		}
+	}
+	return p, nil
+}
+
+// readRows reads every row of a csv file but its header, none when the file doesn't exist yet.
+// Rows with fewer columns than the file had when it started are ignored.
+func readRows(path string, header []string) ([][]string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1 // older stats rows have fewer columns.
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var valid [][]string
+	for _, row := range rows {
+		if len(row) >= 4 && row[0] != header[0] {
+			valid = append(valid, row)
+		}
+	}
+	return valid, nil
 }
 
-func (c csvDb) LoadPlayerStatsByName(name string) *Player {
-	// TODO: fix this!
-	return &Player{}
-	// return new PlayerDTO{ID: playerID, }
+// appendRows appends rows to a csv file, creating it with a header first if needed.
+func appendRows(path string, header []string, rows [][]string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err == nil && info.Size() == 0 {
+		rows = append([][]string{header}, rows...)
+	}
+	if err == nil {
+		err = csv.NewWriter(file).WriteAll(rows)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
 }
 
-func dataToWrite(setId string, gameId int, name string, points int) []string {
+// statsRowKey returns the StatsKey of a stats row with every column.
+func statsRowKey(row []string) string {
+	gameId, _ := strconv.Atoi(row[1])
+	return StatsKey(row[0], gameId, row[len(row)-1])
+}
+
+func dataToWrite(setId string, gameId int, seed int64, name string, points int) []string {
 	gid := fmt.Sprintf("%d", gameId)
 	p := fmt.Sprintf("%d", points)
-	return []string{setId, gid, name, p, time.Now().Local().String()}
+	return []string{setId, gid, name, p, time.Now().Local().String(), fmt.Sprintf("%d", seed)}
+}
+
+// potData returns what happened to a game's pot as seen by a player: the carried pot, the bombs in a row,
+// whether the player contributed to and may rejoin for the carried pot and whether a tie was split by force.
+func potData(pot PotStats, playerId string) []string {
+	return []string{
+		fmt.Sprintf("%d", pot.Carried),
+		fmt.Sprintf("%d", pot.Bombs),
+		fmt.Sprintf("%t", contains(pot.Contributors, playerId)),
+		fmt.Sprintf("%t", contains(pot.Rejoining, playerId)),
+		fmt.Sprintf("%t", pot.ForcedSplit),
+	}
 }
@@ -0,0 +1,117 @@
+package douji
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCSVDbCreatesFilesOnDemand(t *testing.T) {
+	dir := t.TempDir()
+	db := NewCSVIn(dir)
+	if _, err := db.LoadPlayerStatsByName("Liu"); !errors.Is(err, ErrPlayerNotFound) {
+		t.Errorf("expected ErrPlayerNotFound before any file exists but got:%v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "douji.csv")); !os.IsNotExist(err) {
+		t.Errorf("expected no stats file before anything is saved.")
+	}
+	if err := db.SaveGameStats("s", 1, 1, PotStats{}, []PlayerDTO{{Id: "1", Name: "Liu", Points: 90}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveGameStats("s", 2, 1, PotStats{}, []PlayerDTO{{Id: "1", Name: "Liu", Points: 80}}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "douji.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 3 || lines[0] != strings.Join(statsHeader, ",") {
+		t.Errorf("expected a header and 2 rows but got:%v", lines)
+	}
+}
+
+func TestCSVDbLoadsLatestPoints(t *testing.T) {
+	db := NewCSVIn(t.TempDir())
+	id, err := db.CreatePlayer("Liu", "secret", 100)
+	if err != nil || id != "1" {
+		t.Fatalf("expected the first player to get id 1 but got:%s, %v", id, err)
+	}
+	if _, err := db.CreatePlayer("Liu", "other", 100); err == nil {
+		t.Errorf("expected creating a player with a taken name to fail.")
+	}
+	if id, _ := db.CreatePlayer("Wang", "", 50); id != "2" {
+		t.Errorf("expected the second player to get id 2 but got:%s", id)
+	}
+
+	p, err := db.LoadPlayerStatsByName("Liu")
+	if err != nil || p.id != "1" || p.points != 100 {
+		t.Fatalf("expected Liu to start with 100 points but got:%+v, %v", p, err)
+	}
+	for i, points := range []int{110, 95} {
+		if err := db.SaveGameStats("s", i+1, 1, PotStats{}, []PlayerDTO{{Id: "1", Name: "Liu", Points: points}, {Id: "2", Name: "Wang", Points: 50}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p, _ := db.LoadPlayerStatsByName("Liu"); p.points != 95 {
+		t.Errorf("expected Liu's points after the last game but got:%d", p.points)
+	}
+	if _, err := db.LoadPlayerStatsByName("Gu"); !errors.Is(err, ErrPlayerNotFound) {
+		t.Errorf("expected ErrPlayerNotFound for an unknown player but got:%v", err)
+	}
+}
+
+func TestCSVDbConcurrentSaves(t *testing.T) {
+	dir := t.TempDir()
+	db := NewCSVIn(dir)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			set := fmt.Sprintf("set%d", i)
+			if err := db.SaveGameStats(set, 1, 1, PotStats{}, []PlayerDTO{{Id: "1", Name: "Liu", Points: i}, {Id: "2", Name: "Wang", Points: i}}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	rows, err := readRows(filepath.Join(dir, "douji.csv"), statsHeader)
+	if err != nil || len(rows) != 40 {
+		t.Errorf("expected 40 complete rows but got %d, %v", len(rows), err)
+	}
+}
+
+func TestCSVDbKeepsStatsOfEverySet(t *testing.T) {
+	db := NewCSVIn(t.TempDir())
+	names := []string{"Liu", "Wang", "Gu", "Sun"}
+	for _, name := range names {
+		if _, err := db.CreatePlayer(name, "", 100); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for session, games := range []int{3, 1} { // the second set's game has the id of a game of the first one.
+		players := make([]*Player, len(names))
+		for i, name := range names {
+			p, err := db.LoadPlayerStatsByName(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			players[i] = p
+		}
+		s := newTestSet(games, DefaultRules())
+		s.SetSeed(int64(session + 1))
+		if err := s.Run(players, alwaysInMiddleGame{}, db, 1, 1, 0); err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range players {
+			if loaded, err := db.LoadPlayerStatsByName(p.Name); err != nil || loaded.points != p.points {
+				t.Errorf("set %d: expected %s to be loaded with %d points but got:%v, %v", session+1, p.Name, p.points, loaded, err)
+			}
+		}
+	}
+}
@@ -1,5 +1,14 @@
 package douji
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrPlayerNotFound is returned when loading a player who was never created.
+var ErrPlayerNotFound = errors.New("player not found")
+
 type PlayerDTO struct {
 	Id     string `json:"player_id"`
 	Name   string `json:"player_name"`
@@ -7,8 +16,71 @@ type PlayerDTO struct {
 }
 
 type Db interface {
-	SaveGameStats(setId string, gameId int, pnp []PlayerDTO) error
-	LoadPlayerStatsByName(name string) *Player
+	// SaveGameStats saves every player's points after a game together with the seed the game's deck was shuffled by
+	// and what happened to the game's pot. It skips the players whose row of the game is already saved, by their StatsKey,
+	// so saving again after an error which came after the row was stored never duplicates it.
+	SaveGameStats(setId string, gameId int, seed int64, pot PotStats, pnp []PlayerDTO) error
+	// LoadPlayerStatsByName loads a player with their latest points, ErrPlayerNotFound when there's no such player.
+	LoadPlayerStatsByName(name string) (*Player, error)
 	// SaveSet(s *Set) error
 	CreatePlayer(name, password string, points int) (string, error)
 }
+
+// SaveError is a failure to save the stats of a game of a set.
+type SaveError struct {
+	SetId  string
+	GameId int // 1 based, like the game id the stats are saved with.
+	Err    error
+}
+
+func (e *SaveError) Error() string {
+	return fmt.Sprintf("saving game %d of set %s: %v", e.GameId, e.SetId, e.Err)
+}
+
+func (e *SaveError) Unwrap() error { return e.Err }
+
+// SetError collects the games of a set whose stats couldn't be saved. The set is played to the end regardless,
+// so the players' points are right even though the Db misses some games.
+type SetError struct {
+	SetId  string
+	Failed []*SaveError // in the order of the games.
+	Ended  error        // what ended the set before its last game, if anything.
+}
+
+func (e *SetError) Error() string {
+	msgs := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		msgs[i] = f.Error()
+	}
+	msg := fmt.Sprintf("set %s: %d games not saved: %s", e.SetId, len(e.Failed), strings.Join(msgs, "; "))
+	if e.Ended != nil {
+		msg = fmt.Sprintf("%v; %s", e.Ended, msg)
+	}
+	return msg
+}
+
+// Is lets errors.Is look into what ended the set and every failed game.
+func (e *SetError) Is(target error) bool {
+	if e.Ended != nil && errors.Is(e.Ended, target) {
+		return true
+	}
+	for _, f := range e.Failed {
+		if errors.Is(f, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As lets errors.As look into what ended the set and every failed game, finding the first one which matches.
+func (e *SetError) As(target interface{}) bool {
+	if e.Ended != nil && errors.As(e.Ended, target) {
+		return true
+	}
+	for _, f := range e.Failed {
+		if errors.As(f, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package douji
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errStorageDown = errors.New("storage down")
+
+// flakyDb fails to save the games in fail and panics saving the games in panics.
+type flakyDb struct {
+	recordingDb
+	fail, panics map[int]bool
+}
+
+func (db *flakyDb) SaveGameStats(setId string, gameId int, seed int64, pot PotStats, pnp []PlayerDTO) error {
+	if db.panics[gameId] {
+		panic("connection reset")
+	}
+	if db.fail[gameId] {
+		return errStorageDown
+	}
+	return db.recordingDb.SaveGameStats(setId, gameId, seed, pot, pnp)
+}
+
+func TestSetRunCollectsSaveErrors(t *testing.T) {
+	healthy, _, _ := runSeededSet(7)
+
+	players := getFourTestingPlayers()
+	db := &flakyDb{fail: map[int]bool{2: true, 4: true}, panics: map[int]bool{3: true}}
+	s := newTestSet(5, DefaultRules())
+	s.id = "s"
+	s.SetSeed(7)
+	s.SetStatsWriterConfig(StatsWriterConfig{QueueSize: 1, Retries: 1, Backoff: time.Millisecond})
+	err := s.Run(players, alwaysInMiddleGame{}, db, 1, 2, 0)
+
+	var setErr *SetError
+	if !errors.As(err, &setErr) || setErr.SetId != "s" {
+		t.Fatalf("expected a SetError of set s but got:%v", err)
+	}
+	var ids []int
+	for _, f := range setErr.Failed {
+		ids = append(ids, f.GameId)
+	}
+	if len(ids) != 3 || ids[0] != 2 || ids[1] != 3 || ids[2] != 4 {
+		t.Errorf("expected games 2, 3 and 4 to fail in order but got:%v", ids)
+	}
+	if !errors.Is(err, errStorageDown) {
+		t.Errorf("expected the Db's error to be wrapped but got:%v", err)
+	}
+	var saveErr *SaveError
+	if !errors.As(err, &saveErr) || saveErr.GameId != 2 {
+		t.Errorf("expected the first failed game to be found but got:%v", saveErr)
+	}
+	if len(db.seeds) < 2 || db.seeds[2] != 0 {
+		t.Errorf("expected every other game to be saved but got:%v", db.seeds)
+	}
+	for i, p := range players {
+		if p.points != healthy[i].points {
+			t.Errorf("expected %s to end with %d points as if every game was saved but got:%d", p.Name, healthy[i].points, p.points)
+		}
+	}
+}
+
+func TestSetRunWithoutSaveErrors(t *testing.T) {
+	s := newTestSet(3, DefaultRules())
+	if err := s.Run(getFourTestingPlayers(), alwaysInMiddleGame{}, &recordingDb{}, 1, 2, 0); err != nil {
+		t.Errorf("expected no error but got:%v", err)
+	}
+}
+
+var errSeatsGone = errors.New("seats gone")
+
+// a middle game whose players leave the table after the first game.
+type leavingMiddleGame struct {
+	alwaysInMiddleGame
+	games *int
+}
+
+func (md leavingMiddleGame) CheckSeats(players []*Player) error {
+	if *md.games++; *md.games > 1 {
+		return errSeatsGone
+	}
+	return nil
+}
+
+func TestSetRunKeepsSaveErrorsOfAnEndedSet(t *testing.T) {
+	s := newTestSet(3, DefaultRules())
+	s.SetStatsWriterConfig(StatsWriterConfig{QueueSize: 1, Retries: 1, Backoff: time.Millisecond})
+	err := s.Run(getFourTestingPlayers(), leavingMiddleGame{games: new(int)}, &flakyDb{fail: map[int]bool{1: true}}, 1, 2, 0)
+	if !errors.Is(err, errSeatsGone) || !errors.Is(err, errStorageDown) {
+		t.Errorf("expected both the error ending the set and the failed save but got:%v", err)
+	}
+}
+
+func TestNewPlayerReturnsDbError(t *testing.T) {
+	db := NewMemoryDb()
+	if _, err := NewPlayer("Liu", "", 100, db); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := NewPlayer("Liu", "", 100, db); err == nil || p != nil {
+		t.Errorf("expected an error creating a player with a taken name but got:%v, %v", p, err)
+	}
+}
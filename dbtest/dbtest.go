@@ -0,0 +1,127 @@
+// Package dbtest is a conformance suite every douji.Db implementation is expected to pass.
+package dbtest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"douji"
+)
+
+// Run runs the suite against the Db newDb returns, which must be empty and only used by the calling subtest.
+func Run(t *testing.T, newDb func(t *testing.T) douji.Db) {
+	t.Run("CreatePlayer", func(t *testing.T) { testCreatePlayer(t, newDb(t)) })
+	t.Run("LoadLatest", func(t *testing.T) { testLoadLatest(t, newDb(t)) })
+	t.Run("SaveTwice", func(t *testing.T) { testSaveTwice(t, newDb(t)) })
+	t.Run("UnknownPlayer", func(t *testing.T) { testUnknownPlayer(t, newDb(t)) })
+	t.Run("ConcurrentSaves", func(t *testing.T) { testConcurrentSaves(t, newDb(t)) })
+}
+
+// testCreatePlayer checks a created player gets a unique id and starts with their points, and names can't be taken twice.
+func testCreatePlayer(t *testing.T, db douji.Db) {
+	liu, err := db.CreatePlayer("Liu", "secret", 100)
+	if err != nil || liu == "" {
+		t.Fatalf("expected an id for a new player but got:%q, %v", liu, err)
+	}
+	wang, err := db.CreatePlayer("Wang", "secret", 50)
+	if err != nil || wang == "" || wang == liu {
+		t.Fatalf("expected a new id different from %q but got:%q, %v", liu, wang, err)
+	}
+	if _, err := db.CreatePlayer("Liu", "other", 100); err == nil {
+		t.Errorf("expected creating a player with a taken name to fail.")
+	}
+	p, err := db.LoadPlayerStatsByName("Liu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "Liu" || p.Id() != liu || p.Points() != 100 {
+		t.Errorf("expected Liu with id %q and 100 points but got:%s, %q, %d", liu, p.Name, p.Id(), p.Points())
+	}
+}
+
+// testLoadLatest checks a player is loaded with their points after the last game saved.
+func testLoadLatest(t *testing.T, db douji.Db) {
+	liu := create(t, db, "Liu", 100)
+	wang := create(t, db, "Wang", 100)
+	for i, points := range []int{110, 95, 120} {
+		save(t, db, "set", i+1, douji.PlayerDTO{Id: liu, Name: "Liu", Points: points}, douji.PlayerDTO{Id: wang, Name: "Wang", Points: 200 - points})
+	}
+	for name, want := range map[string]int{"Liu": 120, "Wang": 80} {
+		p, err := db.LoadPlayerStatsByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Points() != want {
+			t.Errorf("expected %s to have %d points after the last game but got:%d", name, want, p.Points())
+		}
+	}
+}
+
+// testSaveTwice checks saving a game again, e.g. retrying a save which stored the rows but failed anyway, keeps the rows saved first.
+func testSaveTwice(t *testing.T, db douji.Db) {
+	liu := create(t, db, "Liu", 100)
+	save(t, db, "set", 1, douji.PlayerDTO{Id: liu, Name: "Liu", Points: 90})
+	save(t, db, "set", 2, douji.PlayerDTO{Id: liu, Name: "Liu", Points: 80})
+	save(t, db, "set", 1, douji.PlayerDTO{Id: liu, Name: "Liu", Points: 90})
+	p, err := db.LoadPlayerStatsByName("Liu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Points() != 80 {
+		t.Errorf("expected saving game 1 again to be skipped, leaving Liu with 80 points but got:%d", p.Points())
+	}
+}
+
+// testUnknownPlayer checks loading a player who was never created fails with douji.ErrPlayerNotFound.
+func testUnknownPlayer(t *testing.T, db douji.Db) {
+	create(t, db, "Liu", 100)
+	p, err := db.LoadPlayerStatsByName("Gu")
+	if !errors.Is(err, douji.ErrPlayerNotFound) {
+		t.Errorf("expected ErrPlayerNotFound for an unknown player but got:%v, %v", p, err)
+	}
+}
+
+// testConcurrentSaves checks games saved from many goroutines at once are all kept.
+func testConcurrentSaves(t *testing.T, db douji.Db) {
+	const players = 10
+	ids := make([]string, players)
+	for i := range ids {
+		ids[i] = create(t, db, fmt.Sprintf("p%d", i), 0)
+	}
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			save(t, db, fmt.Sprintf("set%d", i), 1, douji.PlayerDTO{Id: ids[i], Name: fmt.Sprintf("p%d", i), Points: i + 1})
+		}(i)
+	}
+	wg.Wait()
+	for i := range ids {
+		p, err := db.LoadPlayerStatsByName(fmt.Sprintf("p%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Points() != i+1 {
+			t.Errorf("expected p%d to have %d points but got:%d", i, i+1, p.Points())
+		}
+	}
+}
+
+func create(t *testing.T, db douji.Db, name string, points int) string {
+	t.Helper()
+	id, err := db.CreatePlayer(name, "secret", points)
+	if err != nil {
+		t.Fatalf("failed to create %s:%v", name, err)
+	}
+	return id
+}
+
+func save(t *testing.T, db douji.Db, setId string, gameId int, players ...douji.PlayerDTO) {
+	t.Helper()
+	if err := db.SaveGameStats(setId, gameId, 1, douji.PotStats{}, players); err != nil {
+		t.Errorf("failed to save game %d of set %s:%v", gameId, setId, err)
+	}
+}
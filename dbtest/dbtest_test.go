@@ -0,0 +1,15 @@
+package dbtest
+
+import (
+	"testing"
+
+	"douji"
+)
+
+func TestMemoryDb(t *testing.T) {
+	Run(t, func(t *testing.T) douji.Db { return douji.NewMemoryDb() })
+}
+
+func TestCSVDb(t *testing.T) {
+	Run(t, func(t *testing.T) douji.Db { return douji.NewCSVIn(t.TempDir()) })
+}
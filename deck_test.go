@@ -0,0 +1,199 @@
+package douji
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// a middle game always calling the step and always staying in.
+type alwaysInMiddleGame struct{}
+
+func (alwaysInMiddleGame) CallOnce(view TableView, step, end int, lastCall bool) int { return step }
+func (alwaysInMiddleGame) InOrOut(view TableView, callingChip int) bool              { return true }
+
+// a db recording saved stats in memory.
+type recordingDb struct {
+	mu    sync.Mutex
+	seeds map[int]int64
+	pots  map[int]PotStats
+}
+
+func (db *recordingDb) SaveGameStats(setId string, gameId int, seed int64, pot PotStats, pnp []PlayerDTO) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.seeds == nil {
+		db.seeds = map[int]int64{}
+		db.pots = map[int]PotStats{}
+	}
+	db.seeds[gameId] = seed
+	db.pots[gameId] = pot
+	return nil
+}
+
+func (db *recordingDb) LoadPlayerStatsByName(name string) (*Player, error) {
+	return nil, ErrPlayerNotFound
+}
+
+func (db *recordingDb) CreatePlayer(name, password string, points int) (string, error) {
+	return name, nil
+}
+
+func TestNewSeededDeck(t *testing.T) {
+	d1, d2 := NewSeededDeck(42), NewSeededDeck(42)
+	if !reflect.DeepEqual(d1.cards, d2.cards) {
+		t.Errorf("expected decks with the same seed to have the same order.")
+	}
+	if reflect.DeepEqual(d1.cards, NewSeededDeck(43).cards) {
+		t.Errorf("expected decks with different seeds to have different orders.")
+	}
+	if !reflect.DeepEqual(d1.cards, NewDeckFromRand(rand.New(rand.NewSource(42))).cards) {
+		t.Errorf("expected a deck from a rand with the same seed to have the same order.")
+	}
+}
+
+func runSeededSet(seed int64) ([]*Player, *recordingDb, []Event) {
+	players := getFourTestingPlayers()
+	db := &recordingDb{}
+	log := &EventLog{}
+	s := newTestSet(5, DefaultRules())
+	s.SetSeed(seed)
+	s.SetEventSink(log)
+	s.Run(players, alwaysInMiddleGame{}, db, 1, 2, 0)
+	return players, db, log.Events()
+}
+
+func TestSetRunIsReproducibleBySeed(t *testing.T) {
+	p1, db1, events1 := runSeededSet(7)
+	p2, db2, events2 := runSeededSet(7)
+	for i := range p1 {
+		if p1[i].points != p2[i].points {
+			t.Errorf("expected %s to end with the same points in both sets but got %d and %d", p1[i].Name, p1[i].points, p2[i].points)
+		}
+	}
+	if len(db1.seeds) < 5 || !reflect.DeepEqual(db1.seeds, db2.seeds) {
+		t.Errorf("expected the same recorded seed for every game but got %v and %v", db1.seeds, db2.seeds)
+	}
+	if !reflect.DeepEqual(events1, events2) {
+		t.Errorf("expected both sets to publish the same events.")
+	}
+}
+
+func TestReplaySeededSet(t *testing.T) {
+	_, _, events := runSeededSet(11)
+	for _, game := range SplitGames(events) {
+		if _, err := Replay(game); err != nil {
+			t.Errorf("expected every game of the set to replay but got:%v", err)
+		}
+	}
+}
+
+func TestNewSeededDecks(t *testing.T) {
+	if !reflect.DeepEqual(NewSeededDecks(42, 1).cards, NewSeededDeck(42).cards) {
+		t.Errorf("expected one seeded deck to deal the same cards as NewSeededDeck.")
+	}
+	d := NewSeededDecks(42, 3)
+	if d.Remaining() != 3*deckSize {
+		t.Fatalf("expected %d cards but got:%d", 3*deckSize, d.Remaining())
+	}
+	counts := map[Card]int{}
+	for d.Remaining() > 0 {
+		counts[d.DealOne()]++
+	}
+	for c, n := range counts {
+		if n != 3 {
+			t.Errorf("expected 3 copies of %v but got:%d", c, n)
+		}
+	}
+}
+
+func TestStartRejectsTooManyPlayers(t *testing.T) {
+	rules := DefaultRules()
+	if got := rules.MaxPlayers(2); got != 9 {
+		t.Errorf("expected one deck to serve 9 players with two hidden cards but got:%d", got)
+	}
+	var players []*Player
+	for i := 0; i < 10; i++ {
+		players = append(players, NewTestPlayer(fmt.Sprint(i), fmt.Sprint(i), 100))
+	}
+	g := NewGame(0, players, 1, 2, 0, 1, 5, nil, rules)
+	if err := g.start(NewDeck()); !errors.Is(err, ErrNotEnoughCards) {
+		t.Errorf("expected ErrNotEnoughCards but got:%v", err)
+	}
+	if players[0].points != 100 || len(players[0].privateCards) != 0 {
+		t.Errorf("expected no points taken and no cards dealt before rejecting the game.")
+	}
+
+	rules.Decks = 2
+	g = NewGame(0, players, 1, 2, 0, 1, 5, nil, rules)
+	if err := g.start(NewSeededDecks(1, 2)); err != nil {
+		t.Errorf("expected two decks to serve 10 players but got:%v", err)
+	}
+}
+
+func TestSetRunWithTwoDecks(t *testing.T) {
+	rules := DefaultRules()
+	rules.Decks = 2
+	var players []*Player
+	for i := 0; i < 18; i++ {
+		players = append(players, NewTestPlayer(fmt.Sprint(i), fmt.Sprint(i), 100))
+	}
+	log := &EventLog{}
+	s := newTestSet(3, rules)
+	s.SetSeed(5)
+	s.SetEventSink(log)
+	s.Run(players, alwaysInMiddleGame{}, &recordingDb{}, 1, 2, 0)
+	for _, game := range SplitGames(log.Events()) {
+		if _, err := Replay(game); err != nil {
+			t.Errorf("expected every game of the set to replay but got:%v", err)
+		}
+	}
+}
+
+func TestDuplicateCardsScoring(t *testing.T) {
+	two := Card{rank: 2, suit: "♦"}
+	for _, tc := range []struct {
+		name  string
+		cards []Card
+		want  int
+		isfk  bool
+	}{
+		{"two red jokers", []Card{jokerR, jokerR, {rank: 5}}, 43 + 30, false},
+		{"three jokers get the pair bonus once", []Card{jokerR, jokerR, jokerB, {rank: 5}}, 60 + 30, false},
+		{"two wild cards make a four a kind from a pair", []Card{{rank: 9}, {rank: 9}, wildCard, wildCard}, 36 + 60, true},
+		{"two wild cards with a joker", []Card{jokerB, wildCard, wildCard, {rank: 5}}, 17 + 19 + 19 + 5 + 30, false},
+		{"three of a kind and two wild cards", []Card{{rank: 7}, {rank: 7}, {rank: 7}, wildCard, wildCard}, 300, true},
+		{"natural five a kind", []Card{{rank: 7}, {rank: 7}, {rank: 7}, {rank: 7}, {rank: 7}}, 300, true},
+		{"six a kind", []Card{{rank: 7}, {rank: 7}, {rank: 7}, {rank: 7}, {rank: 7}, {rank: 7}}, 300, true},
+		{"two four a kinds", []Card{{rank: 7}, {rank: 7}, {rank: 7}, {rank: 7}, {rank: 8}, {rank: 8}, {rank: 8}, {rank: 8}}, 60 + 120, true},
+		{"wild card kept as a 2 for a four a kind", []Card{two, two, two, wildCard, {rank: 11}, {rank: 11}}, 30 + 60, true},
+		{"wild card making a three a kind still counts as a 2", []Card{wildCard, {rank: 5}, {rank: 4}, {rank: 4}, two, two}, 21 + 60, false},
+	} {
+		if b := DefaultRules().Evaluate(tc.cards); b.Total != tc.want || b.FourKind != tc.isfk {
+			t.Errorf("%s: expected %d (four a kind:%v) but got %d (four a kind:%v)", tc.name, tc.want, tc.isfk, b.Total, b.FourKind)
+		}
+	}
+}
+
+func TestSetRunRejectsTooManyPlayers(t *testing.T) {
+	var players []*Player
+	for i := 0; i < 12; i++ {
+		players = append(players, NewTestPlayer(fmt.Sprint(i), fmt.Sprint(i), 100))
+	}
+	db := &recordingDb{}
+	s := newTestSet(3, DefaultRules())
+	if err := s.Run(players, alwaysInMiddleGame{}, db, 1, 1, 0); !errors.Is(err, ErrNotEnoughCards) {
+		t.Errorf("expected 12 players on one deck to fail with ErrNotEnoughCards but got:%v", err)
+	}
+	for _, p := range players {
+		if p.points != 100 {
+			t.Errorf("expected %s to keep their points but got:%d", p.Name, p.points)
+		}
+	}
+	if len(db.seeds) != 0 {
+		t.Errorf("expected no game saved but got:%v", db.seeds)
+	}
+}
@@ -1,10 +1,13 @@
 package douji
 
 import (
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
-	"sort"
-	"sync"
+	"strconv"
 	"time"
 )
 
@@ -12,18 +15,53 @@ func (c Card) String() string {
 	return fmt.Sprintf("%s%d", c.suit, c.rank)
 }
 
+// Rank returns the card's score, e.g. 11 for J and 21 for the special card.
+func (c Card) Rank() int {
+	return c.rank
+}
+
+// Suit returns the card's suit, jokers and the special card have their own suit.
+func (c Card) Suit() string {
+	return c.suit
+}
+
+type cardJSON struct {
+	Rank int    `json:"rank"`
+	Suit string `json:"suit"`
+}
+
+// MarshalJSON encodes a card as {"rank":10,"suit":"♠"}.
+func (c Card) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cardJSON{c.rank, c.suit})
+}
+
+// UnmarshalJSON decodes a card encoded by MarshalJSON.
+func (c *Card) UnmarshalJSON(data []byte) error {
+	var cj cardJSON
+	if err := json.Unmarshal(data, &cj); err != nil {
+		return err
+	}
+	c.rank, c.suit = cj.Rank, cj.Suit
+	return nil
+}
+
 // AddPlayer adds a new player to an in-process game.
 func (g *Game) AddPlayer(p Player) {
 	g.players = append(g.players, &p)
+	g.seated = append(g.seated, g.players[len(g.players)-1])
 }
 
-func NewGame(id int, players []*Player, base, hiddenCount, pot, step, end int, prevWinner *Player) *Game {
-	mr := 4 // one hidden card game has 4 rounds after start.
-	if hiddenCount > 1 {
-		mr = 5 // with two hidden cards, there are an extra round.
-	}
+// NewCard creates a card, e.g. to choose a RuleSet's wild card. Jokers have suits "joker Black" and "joker Red".
+func NewCard(rank int, suit string) Card {
+	return Card{rank, suit}
+}
+
+// NewGame creates a game played by rules, the default rules for a zero RuleSet. Start refuses rules which can't be played with.
+func NewGame(id int, players []*Player, base, hiddenCount, pot, step, end int, prevWinner *Player, rules RuleSet) *Game {
+	rules = rules.orDefault()
 	return &Game{
 		id:          id,
+		seated:      append([]*Player(nil), players...),
 		players:     players,
 		base:        base,
 		hiddenCount: hiddenCount,
@@ -31,23 +69,69 @@ func NewGame(id int, players []*Player, base, hiddenCount, pot, step, end int, p
 		status:      ready,
 		step:        step,
 		end:         end,
-		maxRound:    mr,
+		maxRound:    rules.rounds(hiddenCount),
 		prevWinner:  prevWinner,
+		rules:       rules,
 	}
 }
 
 // start starts a game by assigning each player certain hidden cards and possibly one public card.
-func (g *Game) start(cardDealer CardDealer) bool {
+// It refuses to start when the rules can't be played with, the deck isn't ready to deal, e.g. a FairDeck waiting for client seeds,
+// has another number of decks than the rules or can't deal every player all the cards they may need.
+func (g *Game) start(cardDealer CardDealer) error {
 	if len(g.players) < 2 {
-		return false // can't start until there are at least two players.
+		return errors.New("can't start until there are at least two players")
+	}
+	if err := g.rules.orDefault().Validate(); err != nil {
+		return err
+	}
+	if ready, ok := cardDealer.(interface{ Ready() error }); ok {
+		if err := ready.Ready(); err != nil {
+			return err
+		}
+	}
+	if decks, ok := cardDealer.(interface{ Decks() int }); ok && decks.Decks() != g.rules.Decks {
+		return fmt.Errorf("%w: the rules play with %d decks but the dealer has %d", ErrWrongDecks, g.rules.Decks, decks.Decks())
+	}
+	available := g.rules.Decks * deckSize
+	if counter, ok := cardDealer.(interface{ Remaining() int }); ok {
+		available = counter.Remaining()
+	}
+	if needed := len(g.players) * g.rules.cardsPerPlayer(g.hiddenCount); needed > available {
+		return fmt.Errorf("%w: %d players need up to %d cards but only %d to deal", ErrNotEnoughCards, len(g.players), needed, available)
+	}
+	started := GameStarted{
+		GameId:      g.id,
+		Seed:        g.seed,
+		Players:     convertToPlayerDTO(g.players),
+		Base:        g.base,
+		HiddenCount: g.hiddenCount,
+		Pot:         g.pot,
+		Step:        g.step,
+		End:         g.end,
+		Rules:       g.rules,
+		Bombs:       g.bombs,
+		BuyIn:       g.buyIn,
+		Newcomers:   g.newcomers,
+	}
+	if g.prevWinner != nil {
+		started.PrevWinnerId = g.prevWinner.id
 	}
+	g.emit(started)
+	g.carried = g.pot
 	bombedPot := g.pot > 0
 	for _, p := range g.players {
+		p.rules = &g.rules
 		if !bombedPot {
-			p.points -= g.base // regardless of how many hidden cards, starting a game only costs one base point for each player unless last game was bombed.
+			g.take(p, g.base) // regardless of how many hidden cards, starting a game only costs one base point for each player unless last game was bombed.
+		} else if g.buyIn > 0 && contains(g.newcomers, p.id) {
+			g.take(p, g.buyIn) // a newcomer buys into the carried pot.
+		} else if p.points <= 0 {
+			g.goAllIn(p, 0) // a player who went all-in last game plays for the carried pot only.
 		}
 		c := cardDealer.DealOne()
 		p.ReceivePrivateCard(c)
+		g.emit(CardDealt{GameId: g.id, PlayerId: p.id, Card: c, Hidden: true})
 	}
 	for _, p := range g.players {
 		c := cardDealer.DealOne()
@@ -56,12 +140,10 @@ func (g *Game) start(cardDealer CardDealer) bool {
 		} else {
 			p.ReceivePublicCard(c) // when there is only one hidden card, each gets a public card.
 		}
-	}
-	if !bombedPot {
-		g.pot += g.base * len(g.players) //
+		g.emit(CardDealt{GameId: g.id, PlayerId: p.id, Card: c, Hidden: g.hiddenCount == 2})
 	}
 	g.status = inProcess
-	return true
+	return nil
 }
 
 func (g *Game) printCurrentStatus(i int) {
@@ -74,26 +156,43 @@ func (g *Game) printCurrentStatus(i int) {
 
 // getAskingPlayers gets asking players in the game with a given calling player index.
 func (g *Game) getAskingPlayers(index int) []*Player {
-	if index == len(g.players)-1 {
-		return g.players[:index] // dealing order starts from the player next to the calling player.
-	}
-	return append(g.players[index+1:], g.players[:index]...) // deals in anti-clock wise order.
+	// always copy, appending to a subslice of g.players would overwrite the players slice the game was created with.
+	asking := make([]*Player, 0, len(g.players)-1)
+	asking = append(asking, g.players[index+1:]...) // dealing order starts from the player next to the calling player.
+	return append(asking, g.players[:index]...)     // deals in anti-clock wise order.
 }
 
-func dealARound(dealer CardDealer, inPlayers []*Player) {
+func (g *Game) dealARound(dealer CardDealer, inPlayers []*Player, round int) {
 	for _, p := range inPlayers {
 		c := dealer.DealOne()
 		p.ReceivePublicCard(c)
+		g.emit(CardDealt{GameId: g.id, Round: round, PlayerId: p.id, Card: c})
 	}
 }
 
-// find the player with largest face score to be the calling player.
+// SetEventSink sets the sink receiving every event published by the game.
+func (g *Game) SetEventSink(es EventSink) {
+	g.events = es
+}
+
+func (g *Game) emit(e Event) {
+	if g.events != nil {
+		g.events.Publish(e)
+	}
+}
+
+func (g *Game) called(cp *Player, points int) {
+	g.calls = append(g.calls, CallRecord{Round: g.round, PlayerId: cp.id, Points: points})
+	g.emit(Called{GameId: g.id, Round: g.round, PlayerId: cp.id, Points: points})
+}
+
+// find the player with largest face score to be the calling player, leaving out all-in players.
 func (g *Game) getCallingPlayerByFaceScore() *Player {
 	var cp *Player
 	fs := 1
 	for _, player := range g.players {
 		pfs := player.FaceScore()
-		if pfs > fs {
+		if pfs > fs && g.canCall(player) {
 			cp = player
 			fs = pfs
 		}
@@ -115,11 +214,18 @@ func (g *Game) getTwoHiddenCallingPlayer(isFirstRound bool) *Player {
 	if isFirstRound && g.prevWinner != nil {
 		p := g.prevWinner
 		g.prevWinner = nil
-		return p
+		if g.canCall(p) {
+			return p
+		}
 	}
-	// in the 1st round when no previous winner (i.e. the 1st game), let first player call.
+	// in the 1st round when no previous winner (i.e. the 1st game), let first player who can call.
 	if isFirstRound {
-		return g.players[0]
+		for _, p := range g.players {
+			if g.canCall(p) {
+				return p
+			}
+		}
+		return nil
 	}
 
 	// in all other situations, calling player is chosen according to face value.
@@ -133,86 +239,18 @@ func (g *Game) getCallingPlayer(isFirstRound bool) *Player {
 	return g.getTwoHiddenCallingPlayer(isFirstRound)
 }
 
-// run a game and return its winner player with the finished game pot. Unless it's bombed pot, the ending pot is 0.
-func (g *Game) run(print bool, md MiddleGame, cardDealer CardDealer) (*Player, int) {
-	if !g.start(cardDealer) {
-		panic("failed to start the game.")
-	}
-	if print {
-		fmt.Println("Game started!")
-		g.printCurrentStatus(0)
-	}
-
-	for i := 1; i <= g.maxRound; i++ {
-		cp := g.getCallingPlayer(i == 1)
-		callingPoint := md.CallOnce(cp, g.step, g.end, i == g.maxRound)
-		for callingPoint == 0 {
-			idx := getPlayerIndex(cp, g.players)
-			g.players = g.getAskingPlayers(idx)
-			if len(g.players) == 1 {
-				break // one player left, game over, break from the inner for loop.
-			}
-			cp = g.getCallingPlayer(i == 1)
-			callingPoint = md.CallOnce(cp, g.step, g.end, i == g.maxRound)
-		}
-		if len(g.players) == 1 {
-			break // one player left, game over! break from the outer loop.
-		}
-		cp.points -= callingPoint  // update calling player's chips.
-		g.pot += callingPoint      // update pot
-		inPlayers := []*Player{cp} // calling player always remains in the game.
-		callingIndex := getPlayerIndex(cp, g.players)
-		askingPlayers := g.getAskingPlayers(callingIndex)
-		for _, player := range askingPlayers {
-			if md.InOrOut(player, callingPoint) {
-				player.points -= callingPoint
-				g.pot += callingPoint
-				inPlayers = append(inPlayers, player)
-			}
-		}
-		g.players = inPlayers
-		if len(g.players) == 1 {
-			break // game over as only calling player is left.
-		}
-		if i < g.maxRound { // deal a round before the last round.
-			dealARound(cardDealer, inPlayers)
-		}
-		if print {
-			g.printCurrentStatus(i)
-		}
-	}
-
-	// more than 1 player in the final round,
-	if len(g.players) > 1 {
-		// check for four a kind!
-		if fkp, ok := checkFourKind(g.players); ok {
-			fkp.points += g.pot
-			g.status = over
-			return fkp, 0
-		}
-
-		// no single four kind, check for final score to determine a winner.
-		sort.Slice(g.players, func(i, j int) bool {
-			return g.players[i].FinalScore() > g.players[j].FinalScore()
-		})
-		// check for bombing pot.
-		if g.players[0].FinalScore() == g.players[1].FinalScore() {
-			g.status = bombing
-			return nil, g.pot // it's a tie so no winner yet.
-		}
-	}
-
-	g.players[0].points += g.pot // update winner's chips.
-	g.pot = 0
-	g.status = over
-	return g.players[0], 0
+// pay gives amount of the pot to the winner.
+func (g *Game) pay(winner *Player, amount int) {
+	winner.points += amount
+	g.pot -= amount
+	g.emit(WinnerPaid{GameId: g.id, PlayerId: winner.id, Pot: amount, Points: winner.points})
 }
 
 // check whether there is any player having four a kind, if so return the player and true.
 func checkFourKind(players []*Player) (*Player, bool) {
 	var fourKindPlayers []*Player
 	for _, p := range players {
-		if p.isFourKind {
+		if p.IsFourKind() {
 			fourKindPlayers = append(fourKindPlayers, p)
 		}
 	}
@@ -230,41 +268,75 @@ func convertToPlayerDTO(players []*Player) []PlayerDTO {
 	return pdtos
 }
 
-func (s Set) Run(players []*Player, md MiddleGame, db Db, base int, hiddenCount int, pot int) {
-	step := 1 // s.step
-	end := 5  // s.end
+// Run plays the games of the set, saving every game's stats to db in the background through a StatsWriter.
+// A game which couldn't be saved doesn't stop the set; every such failure is returned in a *SetError once the set is over
+// and every game is saved. A game which can't start, e.g. with more players than the decks serve, ends the set with its error,
+// which is the *SetError's Ended when games before it failed to save.
+func (s Set) Run(players []*Player, md MiddleGame, db Db, base int, hiddenCount int, pot int) error {
+	step := s.rules.Step
+	end := s.rules.End
 	var prevWinner *Player
-	var wg sync.WaitGroup
+	w := NewStatsWriter(db, s.writer)
+	defer w.Close()
+	seeds := rand.New(rand.NewSource(s.seed)) // every game's deck seed derives from the set seed so a whole set can be re-dealt.
+	players = append([]*Player(nil), players...)
+	var carried *carriedPot
 	for i := 0; i < s.gameNumber; i++ {
-		game := NewGame(i, players, base, hiddenCount, pot, step, end, prevWinner)
-		prevWinner, pot = game.run(s.printStatus, md, NewDeck())
-		pdtos := convertToPlayerDTO(players)
-		wg.Add(1)
-		go func(i int) {
-			defer wg.Done()
-			if err := db.SaveGameStats(s.id, i+1, pdtos); err != nil {
-				panic(err)
+		players = append(players, s.joined()...)
+		playing := playersWithPoints(players) // players who have lost all their points sit the rest of the set out.
+		var newcomers []string
+		if carried != nil {
+			playing, newcomers = carried.players(s.rules, players)
+		}
+		if len(playing) < 2 {
+			break
+		}
+		if prevWinner != nil && !containsPlayer(playing, prevWinner) {
+			prevWinner = nil // a previous winner sitting out the game doesn't call first.
+		}
+		game := NewGame(i, playing, base, hiddenCount, pot, step, end, prevWinner, s.rules)
+		game.SetEventSink(s.events)
+		game.SetDecisionTimeouts(s.timeouts)
+		game.seed = seeds.Int63()
+		if carried != nil {
+			game.bombs, game.carriedShares = carried.bombs, carried.shares
+			game.buyIn, game.newcomers = carried.buyIn(s.rules), newcomers
+		}
+		var err error
+		if prevWinner, pot, err = game.run(s.printStatus, md, NewSeededDecks(game.seed, s.rules.Decks)); err != nil {
+			err = fmt.Errorf("game %d of set %s: %w", i+1, s.id, err) // nothing was dealt or paid, the players keep their points.
+			if setErr, ok := w.Flush(s.id).(*SetError); ok {
+				setErr.Ended = err // the games before still failed to save.
+				return setErr
 			}
-		}(i)
+			return err
+		}
+		carried = game.carryOver(pot)
+		pdtos := convertToPlayerDTO(players)
+		stats := carried.stats(s.rules, players)
+		stats.ForcedSplit = game.forcedSplit
+		w.Write(s.id, i+1, game.seed, stats, pdtos)
 		// this is just for debugging.
 		if s.printStatus {
 			if prevWinner != nil {
 				fmt.Printf("Game %d winner is:%s\n", i, prevWinner.Name)
 			}
 			for _, player := range players {
-				fmt.Printf("%s-%d-%v-%v\n", player.Name, player.FinalScore(), player.privateCards, player.publicCards)
+				fmt.Printf("%s-%v-%v: %v\n", player.Name, player.privateCards, player.publicCards, player.FinalBreakdown())
 			}
 		}
 		for _, p := range players {
 			p.ClearHand()
 		}
-		if pot > 0 { // bombed pot!
-			s.gameNumber++ // add an extra game when there is a bombed pot.
-			step *= 2      // double the step with every bobmed pot.
-			end *= 2       // double the end with every bombed pot.
+		if pot > 0 && carried.tieBreakers != nil {
+			s.gameNumber++ // add an extra game to break the tie at the usual stakes.
+		} else if pot > 0 { // bombed pot!
+			s.gameNumber++             // add an extra game when there is a bombed pot.
+			step *= s.rules.BombFactor // raise the step with every bobmed pot.
+			end *= s.rules.BombFactor  // raise the end with every bombed pot.
 		} else {
-			step = 1 // s.step // revert back to the original data.
-			end = 5  // s.end
+			step = s.rules.Step // revert back to the original data.
+			end = s.rules.End
 		}
 	}
 	if s.printStatus {
@@ -273,17 +345,26 @@ func (s Set) Run(players []*Player, md MiddleGame, db Db, base int, hiddenCount
 			fmt.Printf("%s(%d)-%d\n", p.Name, p.points, p.FinalScore())
 		}
 	}
-	wg.Wait() // make sure the last game result is saved before exit.
+	return w.Flush(s.id) // make sure the last game result is saved before exit.
 }
 
-// creates unshuffled cards.
+// deckSize is the number of cards in one deck.
+const deckSize = 55
+
+// ErrNotEnoughCards is returned when a game has more players than its deck can serve.
+var ErrNotEnoughCards = errors.New("not enough cards")
+
+// ErrWrongDecks is returned when a game is dealt from a dealer shuffling another number of decks than the rules play with.
+var ErrWrongDecks = errors.New("wrong number of decks")
+
+// creates unshuffled cards of one deck.
 func createCards() []Card {
 	var (
 		// for quickness, use int to represent ranks.
 		ranks = []int{2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 15} // J-11, Q-12, K-13, A-15
 		suits = []string{"♦", "♣", "♥", "♠"}
 	)
-	cards := make([]Card, 0, 55)
+	cards := make([]Card, 0, deckSize)
 	for _, r := range ranks {
 		for _, s := range suits {
 			cards = append(cards, Card{r, s})
@@ -292,16 +373,48 @@ func createCards() []Card {
 	return append(cards, jokerB, jokerR, Card{21, "special"})
 }
 
+// creates unshuffled cards of n decks.
+func createDecks(n int) []Card {
+	cards := make([]Card, 0, n*deckSize)
+	for i := 0; i < n; i++ {
+		cards = append(cards, createCards()...)
+	}
+	return cards
+}
+
 // NewDeck creates a new randomly shuffle deck of 55 cards.
 func NewDeck() *Deck {
-	cards := createCards()
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(cards), func(i, j int) {
+	return NewSeededDeck(time.Now().UnixNano())
+}
+
+// NewSeededDeck creates a deck of 55 cards shuffled by the given seed; the same seed always deals the same cards.
+func NewSeededDeck(seed int64) *Deck {
+	return NewDeckFromRand(rand.New(rand.NewSource(seed)))
+}
+
+// NewDeckFromRand creates a deck of 55 cards shuffled by r. r is not safe for concurrent use so it shall not be shared between sets running in parallel.
+func NewDeckFromRand(r *rand.Rand) *Deck {
+	return newDecksFromRand(r, 1)
+}
+
+// NewSeededDecks creates n decks shuffled together by the given seed. One deck deals the same cards as NewSeededDeck.
+func NewSeededDecks(seed int64, n int) *Deck {
+	return newDecksFromRand(rand.New(rand.NewSource(seed)), n)
+}
+
+func newDecksFromRand(r *rand.Rand, n int) *Deck {
+	cards := createDecks(n)
+	r.Shuffle(len(cards), func(i, j int) {
 		cards[i], cards[j] = cards[j], cards[i]
 	})
 	return &Deck{cards}
 }
 
+// Remaining returns the number of cards left in the deck.
+func (d *Deck) Remaining() int {
+	return len(d.cards)
+}
+
 // DealOne deals one card from the the deck.
 func (d *Deck) DealOne() Card {
 	if len(d.cards) == 0 {
@@ -312,6 +425,41 @@ func (d *Deck) DealOne() Card {
 	return c
 }
 
-func NewSet(gameNumber int, printStatus bool) Set {
-	return Set{gameNumber: gameNumber, printStatus: printStatus}
+// NewSet creates a set of games played by rules, the default rules for a zero RuleSet.
+// It returns an error when the rules can't be played with.
+func NewSet(gameNumber int, printStatus bool, rules RuleSet) (Set, error) {
+	rules = rules.orDefault()
+	if err := rules.Validate(); err != nil {
+		return Set{}, err
+	}
+	return Set{id: newSetId(), gameNumber: gameNumber, printStatus: printStatus, seed: time.Now().UnixNano(), rules: rules, joins: &joinQueue{}}, nil
+}
+
+// newSetId returns a random set id, so the stats of different sets never share a StatsKey.
+func newSetId() string {
+	b := make([]byte, 8)
+	if _, err := crand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// SetSeed sets the seed from which every game's deck in the set is shuffled.
+func (s *Set) SetSeed(seed int64) {
+	s.seed = seed
+}
+
+// SetStatsWriterConfig sets how the stats of the set's games are queued and retried.
+func (s *Set) SetStatsWriterConfig(cfg StatsWriterConfig) {
+	s.writer = cfg
+}
+
+// Seed returns the seed from which every game's deck in the set is shuffled.
+func (s Set) Seed() int64 {
+	return s.seed
+}
+
+// SetEventSink sets the sink receiving the events of every game in the set.
+func (s *Set) SetEventSink(es EventSink) {
+	s.events = es
 }
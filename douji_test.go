@@ -15,7 +15,7 @@ func TestNewDeck(t *testing.T) {
 }
 
 func TestAddNewPlayer(t *testing.T) {
-	g := NewGame(0, []*Player{}, 1, 1, 0, 1, 5, nil)
+	g := NewGame(0, []*Player{}, 1, 1, 0, 1, 5, nil, DefaultRules())
 	p := NewTestPlayer("p1", "100", 1)
 
 	g.AddPlayer(*p)
@@ -48,7 +48,7 @@ func TestStartGame(t *testing.T) {
 		}, 0},
 	} {
 		base := 1
-		g := NewGame(0, tc.players, base, tc.hiddenCount, 0, 1, 5, nil)
+		g := NewGame(0, tc.players, base, tc.hiddenCount, 0, 1, 5, nil, DefaultRules())
 		g.start(NewDeck())
 		for _, p := range tc.players {
 			if len(p.publicCards) != tc.pcc {
@@ -64,6 +64,15 @@ func TestStartGame(t *testing.T) {
 	}
 }
 
+// newTestSet creates a set of rules which are known to be valid.
+func newTestSet(gameNumber int, rules RuleSet) Set {
+	s, err := NewSet(gameNumber, false, rules)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
 func getFourTestingPlayers() []*Player {
 	return []*Player{
 		{Name: "Liu", points: 100, id: "0"},
@@ -73,6 +82,24 @@ func getFourTestingPlayers() []*Player {
 	}
 }
 
+// viewMatcher matches a TableView belonging to a player.
+type viewMatcher struct {
+	p *Player
+}
+
+func viewOf(p *Player) gomock.Matcher {
+	return viewMatcher{p}
+}
+
+func (m viewMatcher) Matches(x interface{}) bool {
+	v, ok := x.(TableView)
+	return ok && v.Self().Id == m.p.id
+}
+
+func (m viewMatcher) String() string {
+	return "is the view of " + m.p.Name
+}
+
 func getStubs(players []*Player, ctrl *gomock.Controller) (*MockCardDealer, *MockMiddleGame) {
 	mg := NewMockMiddleGame(ctrl)
 	cardDealer := NewMockCardDealer(ctrl)
@@ -94,13 +121,13 @@ func getStubs(players []*Player, ctrl *gomock.Controller) (*MockCardDealer, *Moc
 	// first round.
 	callingChip := 1
 	mg.EXPECT().
-		CallOnce(players[0], 1, 5, false). // Liu is calling 1 for 2 times.
+		CallOnce(viewOf(players[0]), 1, 5, false). // Liu is calling 1 for 2 times.
 		Return(callingChip).
 		Times(2)
 
-	mg.EXPECT().InOrOut(players[1], callingChip).Return(true)
-	mg.EXPECT().InOrOut(players[2], callingChip).Return(true)
-	mg.EXPECT().InOrOut(players[3], callingChip).Return(true)
+	mg.EXPECT().InOrOut(viewOf(players[1]), callingChip).Return(true)
+	mg.EXPECT().InOrOut(viewOf(players[2]), callingChip).Return(true)
+	mg.EXPECT().InOrOut(viewOf(players[3]), callingChip).Return(true)
 
 	// deal 2nd public card
 	gomock.InOrder(
@@ -111,9 +138,9 @@ func getStubs(players []*Player, ctrl *gomock.Controller) (*MockCardDealer, *Moc
 		cardDealer.EXPECT().DealOne().Return(Card{rank: 5}),
 	)
 	// Liu called 1 again for the 2nd time.
-	mg.EXPECT().InOrOut(players[1], 1).Return(true)
-	mg.EXPECT().InOrOut(players[2], 1).Return(false) // Gu is out of the game
-	mg.EXPECT().InOrOut(players[3], 1).Return(true)
+	mg.EXPECT().InOrOut(viewOf(players[1]), 1).Return(true)
+	mg.EXPECT().InOrOut(viewOf(players[2]), 1).Return(false) // Gu is out of the game
+	mg.EXPECT().InOrOut(viewOf(players[3]), 1).Return(true)
 
 	gomock.InOrder(
 		// public cards.
@@ -123,9 +150,9 @@ func getStubs(players []*Player, ctrl *gomock.Controller) (*MockCardDealer, *Moc
 	)
 
 	// 3nd public card; Sun called 1.
-	mg.EXPECT().CallOnce(players[3], 1, 5, false).Return(1)
-	mg.EXPECT().InOrOut(players[0], 1).Return(true)
-	mg.EXPECT().InOrOut(players[1], 1).Return(false) // Wang is out of the game
+	mg.EXPECT().CallOnce(viewOf(players[3]), 1, 5, false).Return(1)
+	mg.EXPECT().InOrOut(viewOf(players[0]), 1).Return(true)
+	mg.EXPECT().InOrOut(viewOf(players[1]), 1).Return(false) // Wang is out of the game
 
 	gomock.InOrder(
 		// public cards.
@@ -134,9 +161,9 @@ func getStubs(players []*Player, ctrl *gomock.Controller) (*MockCardDealer, *Moc
 	)
 	// Sun called 2.
 	mg.EXPECT().
-		CallOnce(players[3], 1, 5, true).
+		CallOnce(viewOf(players[3]), 1, 5, true).
 		Return(2)
-	mg.EXPECT().InOrOut(players[0], 2).Return(true)
+	mg.EXPECT().InOrOut(viewOf(players[0]), 2).Return(true)
 
 	return cardDealer, mg
 }
@@ -146,8 +173,8 @@ func TestRun(t *testing.T) {
 	hiddenCount := 1
 	base := 1
 	cardDealer, mg := getStubs(players, gomock.NewController(t))
-	game := NewGame(0, players, base, hiddenCount, 0, 1, 5, nil)
-	winner, pot := game.run(false, mg, cardDealer)
+	game := NewGame(0, players, base, hiddenCount, 0, 1, 5, nil, DefaultRules())
+	winner, pot, _ := game.run(false, mg, cardDealer)
 	if winner.Name != "Liu" {
 		t.Errorf("Expected Liu wins the game but got:%s", winner.Name)
 	}
@@ -202,7 +229,7 @@ func Test_PublicScoreFourKind(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		ps := tc.p.PublicScore()
-		if !tc.p.isFourKind {
+		if !tc.p.PublicBreakdown().FourKind {
 			t.Fatalf("%s:should be four kind.", tc.desc)
 		}
 		if ps != tc.result.fs {
@@ -274,7 +301,7 @@ func Test_FinalScoreFourKind(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		fs := tc.p.FinalScore()
-		if tc.p.isFourKind != tc.result.isfk {
+		if tc.p.IsFourKind() != tc.result.isfk {
 			t.Fatalf("%s:should be four kind", tc.desc)
 		}
 		if fs != tc.result.fs {
@@ -590,6 +617,22 @@ func TestPlayer_FinalScore(t *testing.T) {
 			},
 			want: 19 + 4*4 + 60,
 		},
+		{
+			name: "2 hidden cards and 4 public cards, wild card making a three a kind still counts as a 2",
+			fields: fields{
+				privateCards: []Card{{rank: 2}, {rank: 2}},
+				publicCards:  []Card{wildCard, {rank: 5}, {rank: 4}, {rank: 4}},
+			},
+			want: 2*2 + 5 + 4*3 + 60,
+		},
+		{
+			name: "2 hidden cards and 4 public cards, wild card leads to two jokers and still counts as a 2",
+			fields: fields{
+				privateCards: []Card{{rank: 2}, {rank: 2}},
+				publicCards:  []Card{wildCard, {rank: 5}, jokerR, {rank: 6}},
+			},
+			want: 2*2 + 5 + 6 + 17 + 19 + 30 + 30,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -747,9 +790,16 @@ func TestGame_GetAskingPlayers(t *testing.T) {
 				hiddenCount: tt.fields.hiddenCount,
 				// pointRange:  tt.fields.pointRange,
 			}
+			before := append([]*Player(nil), g.players...)
 			if got := g.getAskingPlayers(tt.args); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Game.GetAskingPlayers() = %v, want %v", got, tt.want)
 			}
+			if got := g.getAskingPlayers(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Game.GetAskingPlayers() = %v, want %v on the 2nd call", got, tt.want)
+			}
+			if !reflect.DeepEqual(g.players, before) {
+				t.Errorf("expected game players to be untouched but got %v", g.players)
+			}
 		})
 	}
 }
@@ -854,7 +904,7 @@ func BenchmarkRun(b *testing.B) {
 		hiddenCount := 1
 		base := 1
 		cardDealer, mg := getStubs(players, gomock.NewController(b))
-		game := NewGame(0, players, base, hiddenCount, 0, 1, 5, nil)
+		game := NewGame(0, players, base, hiddenCount, 0, 1, 5, nil, DefaultRules())
 		game.run(false, mg, cardDealer)
 	}
 }
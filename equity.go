@@ -0,0 +1,152 @@
+package douji
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// EquityQuery is what a player knows about a game in progress.
+type EquityQuery struct {
+	Hidden    []Card   // the player's own hidden cards.
+	Public    []Card   // the player's own public cards.
+	Opponents [][]Card // public cards of every opponent still in the game.
+	Folded    []Card   // public cards of players out of the game, which can't be dealt again.
+	ToDeal    int      // public cards every player still in the game is yet to receive.
+	Rules     RuleSet  // the rules hands are scored by, the zero value for the default rules.
+}
+
+// EquityEstimate is the chance of each outcome of the final round.
+type EquityEstimate struct {
+	Win    float64
+	Bomb   float64 // the top final scores tie and the pot carries to the next game.
+	Lose   float64
+	Trials int
+}
+
+// Equity is the share of the pot the player can expect, counting a bombed pot as half a win since it carries over to the next game.
+func (e EquityEstimate) Equity() float64 {
+	return e.Win + e.Bomb/2
+}
+
+// EstimateEquity simulates the rest of a game trials times by dealing opponents' hidden cards and all public cards still to come
+// from the cards the player hasn't seen, and decides every final round with the game's own scoring and four a kind rule.
+// It assumes every opponent stays in until the final round.
+func EstimateEquity(q EquityQuery, trials int, r *rand.Rand) (EquityEstimate, error) {
+	rules := q.Rules.orDefault()
+	unseen := unseenCards(q, rules.Decks)
+	hiddenCount := len(q.Hidden)
+	needed := len(q.Opponents)*hiddenCount + (len(q.Opponents)+1)*q.ToDeal
+	if needed > len(unseen) {
+		return EquityEstimate{}, fmt.Errorf("%d cards needed to finish the game but only %d unseen", needed, len(unseen))
+	}
+
+	var wins, bombs int
+	hands := make([]*Player, len(q.Opponents)+1)
+	for t := 0; t < trials; t++ {
+		// partially shuffle just the cards needed for this trial.
+		for i := 0; i < needed; i++ {
+			j := i + r.Intn(len(unseen)-i)
+			unseen[i], unseen[j] = unseen[j], unseen[i]
+		}
+		deal := unseen[:needed]
+		hands[0] = &Player{id: "self", rules: &rules, Hand: Hand{
+			privateCards: append([]Card(nil), q.Hidden...),
+			publicCards:  append(append([]Card(nil), q.Public...), deal[:q.ToDeal]...),
+		}}
+		deal = deal[q.ToDeal:]
+		for i, op := range q.Opponents {
+			hands[i+1] = &Player{id: fmt.Sprint(i), rules: &rules, Hand: Hand{
+				privateCards: append([]Card(nil), deal[:hiddenCount]...),
+				publicCards:  append(append([]Card(nil), op...), deal[hiddenCount:hiddenCount+q.ToDeal]...),
+			}}
+			deal = deal[hiddenCount+q.ToDeal:]
+		}
+		switch winner, bombed := decideFinalRound(hands); {
+		case bombed:
+			bombs++
+		case winner == hands[0]:
+			wins++
+		}
+	}
+	if trials == 0 {
+		return EquityEstimate{}, nil
+	}
+	n := float64(trials)
+	return EquityEstimate{Win: float64(wins) / n, Bomb: float64(bombs) / n, Lose: float64(trials-wins-bombs) / n, Trials: trials}, nil
+}
+
+// ViewEquity estimates a player's chance of winning from what they can see at the table.
+func ViewEquity(view TableView, trials int, r *rand.Rand) (EquityEstimate, error) {
+	q := EquityQuery{Hidden: view.HiddenCards(), Public: view.Self().PublicCards(), ToDeal: view.MaxRound() - view.Round(), Rules: view.Rules()}
+	for _, s := range view.Seats() {
+		switch {
+		case s.Id == view.Self().Id:
+		case s.In:
+			q.Opponents = append(q.Opponents, s.PublicCards())
+		default:
+			q.Folded = append(q.Folded, s.PublicCards()...)
+		}
+	}
+	return EstimateEquity(q, trials, r)
+}
+
+// MonteCarloEquity returns an equity function for PotOddsBot simulating trials games per decision.
+func MonteCarloEquity(trials int, seed int64) func(TableView) float64 {
+	r := rand.New(rand.NewSource(seed))
+	return func(view TableView) float64 {
+		e, err := ViewEquity(view, trials, r)
+		if err != nil {
+			return quickEquity(view)
+		}
+		return e.Equity()
+	}
+}
+
+// unseenCards returns every card of the decks the player hasn't seen.
+func unseenCards(q EquityQuery, decks int) []Card {
+	seen := append(append(append([]Card(nil), q.Hidden...), q.Public...), q.Folded...)
+	for _, op := range q.Opponents {
+		seen = append(seen, op...)
+	}
+	var unseen []Card
+	for _, c := range createDecks(decks) {
+		found := false
+		for i, s := range seen {
+			if isCard(c, s) {
+				seen = append(seen[:i], seen[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			unseen = append(unseen, c)
+		}
+	}
+	return unseen
+}
+
+// decideFinalRound decides the final round between players with complete hands.
+// A single four a kind wins regardless of scores, otherwise the largest final score wins and a tie bombs the pot.
+func decideFinalRound(players []*Player) (*Player, bool) {
+	scores := make(map[*Player]int, len(players))
+	for _, p := range players {
+		scores[p] = p.FinalScore()
+	}
+	if fkp, ok := checkFourKind(players); ok {
+		return fkp, false
+	}
+	var winner *Player
+	best, tied := -1, false
+	for _, p := range players {
+		switch s := scores[p]; {
+		case s > best:
+			winner, best, tied = p, s, false
+		case s == best:
+			tied = true
+		}
+	}
+	if tied {
+		return nil, true
+	}
+	return winner, false
+}
@@ -0,0 +1,90 @@
+package douji
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func suited(rank int, suit string) Card {
+	return Card{rank: rank, suit: suit}
+}
+
+func TestEstimateEquityCertainWin(t *testing.T) {
+	q := EquityQuery{
+		Hidden:    []Card{wildCard},
+		Public:    []Card{suited(15, "♦"), suited(15, "♣"), suited(15, "♥"), suited(15, "♠")}, // five A's with the wild card.
+		Opponents: [][]Card{{suited(3, "♦"), suited(4, "♦"), suited(5, "♦"), suited(6, "♦")}},
+	}
+	e, err := EstimateEquity(q, 200, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Win != 1 || e.Trials != 200 {
+		t.Errorf("expected five a kind to always win but got:%+v", e)
+	}
+}
+
+func TestEstimateEquityCertainLoss(t *testing.T) {
+	q := EquityQuery{
+		Hidden:    []Card{suited(3, "♣")},
+		Public:    []Card{suited(4, "♣"), suited(5, "♣"), suited(6, "♣"), suited(7, "♣")},
+		Opponents: [][]Card{{suited(13, "♦"), suited(13, "♣"), suited(13, "♥"), suited(13, "♠")}}, // public four a kind always wins.
+	}
+	e, err := EstimateEquity(q, 200, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Lose != 1 || e.Equity() != 0 {
+		t.Errorf("expected to always lose against a public four a kind but got:%+v", e)
+	}
+}
+
+func TestEstimateEquityProbabilities(t *testing.T) {
+	q := EquityQuery{
+		Hidden:    []Card{suited(10, "♣"), suited(11, "♦")},
+		Public:    []Card{suited(12, "♣")},
+		Opponents: [][]Card{{suited(9, "♦")}, {jokerR}},
+		Folded:    []Card{suited(2, "♠")},
+		ToDeal:    3,
+	}
+	e, err := EstimateEquity(q, 2000, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(e.Win+e.Bomb+e.Lose-1) > 1e-9 {
+		t.Errorf("expected outcome probabilities to sum to 1 but got:%+v", e)
+	}
+	if e.Win < 0.15 || e.Win > 0.7 {
+		t.Errorf("expected a reasonable chance against two opponents but got:%+v", e)
+	}
+}
+
+func TestEstimateEquityNotEnoughCards(t *testing.T) {
+	q := EquityQuery{Hidden: []Card{suited(3, "♣")}, Opponents: make([][]Card, 20), ToDeal: 3}
+	if _, err := EstimateEquity(q, 10, rand.New(rand.NewSource(1))); err == nil {
+		t.Errorf("expected an error when the deck can't finish the game.")
+	}
+}
+
+func TestUnseenCards(t *testing.T) {
+	q := EquityQuery{Hidden: []Card{wildCard}, Public: []Card{jokerR}, Opponents: [][]Card{{jokerB}}, Folded: []Card{suited(21, "special")}}
+	unseen := unseenCards(q, 1)
+	if len(unseen) != 51 {
+		t.Fatalf("expected 51 unseen cards but got:%d", len(unseen))
+	}
+	for _, c := range unseen {
+		if isCard(c, wildCard) || isCard(c, jokerR) || isCard(c, jokerB) || c.rank == 21 {
+			t.Errorf("expected %v to be seen", c)
+		}
+	}
+}
+
+func TestPotOddsBotWithMonteCarloEquity(t *testing.T) {
+	players := getFourTestingPlayers()
+	d := NewDispatcher(ThresholdBot{Fold: 10, Raise: 20})
+	d.Seat("0", PotOddsBot{Equity: MonteCarloEquity(50, 1)})
+	s := newTestSet(3, DefaultRules())
+	s.SetSeed(4)
+	s.Run(players, d, &recordingDb{}, 1, 1, 0)
+}
@@ -0,0 +1,192 @@
+package douji
+
+import "sync"
+
+// Event is a single state change in a game. Every change to the pot, a player's points or the players in a game is published as an event.
+type Event interface {
+	// Kind returns the name of the event type, e.g. "CardDealt".
+	Kind() string
+}
+
+// EventSink receives the events published by a running game.
+type EventSink interface {
+	Publish(e Event)
+}
+
+// EventSinkFunc allows an ordinary function to be used as an EventSink.
+type EventSinkFunc func(e Event)
+
+// Publish calls f(e).
+func (f EventSinkFunc) Publish(e Event) {
+	f(e)
+}
+
+// EventLog is an EventSink recording every published event in order. It's safe for concurrent use.
+type EventLog struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// Publish appends an event to the log.
+func (l *EventLog) Publish(e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, e)
+}
+
+// Events returns a copy of all recorded events.
+func (l *EventLog) Events() []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Event(nil), l.events...)
+}
+
+// GameStarted is published before any card is dealt. Players holds each player's points before paying the base.
+type GameStarted struct {
+	GameId       int
+	Seed         int64 // seed of the shuffled deck, 0 if unknown.
+	Players      []PlayerDTO
+	Base         int
+	HiddenCount  int
+	Pot          int // starting pot, non-zero only when last game was bombed.
+	Step         int
+	End          int
+	PrevWinnerId string
+	Rules        RuleSet
+	Bombs        int      // pots in a row carried over into the game.
+	BuyIn        int      // points each newcomer puts in to play for the carried pot.
+	Newcomers    []string // ids of the players who didn't put points into the carried pot.
+}
+
+// CardDealt is published for every card dealt to a player. Round 0 is the initial deal when a game starts.
+type CardDealt struct {
+	GameId   int
+	Round    int
+	PlayerId string
+	Card     Card
+	Hidden   bool
+}
+
+// Called is published when a calling player calls some points; 0 means quitting the game.
+type Called struct {
+	GameId   int
+	Round    int
+	PlayerId string
+	Points   int
+}
+
+// CallRejected is published when a MiddleGame makes an invalid call and is asked again. After maxCallAttempts rejected calls
+// in a row the player quits the game, published as a Called with 0 points.
+type CallRejected struct {
+	GameId   int
+	Round    int
+	PlayerId string
+	Points   int
+	Reason   string
+}
+
+// InOrOutDecided is published when an asking player decides to stay in or go out after a call.
+type InOrOutDecided struct {
+	GameId   int
+	Round    int
+	PlayerId string
+	Points   int
+	In       bool
+}
+
+// PlayerFolded is published when a player leaves a game, either by calling 0 or by choosing out.
+type PlayerFolded struct {
+	GameId   int
+	Round    int
+	PlayerId string
+}
+
+// RoundEnded is published at the end of each played round with the players still in the game.
+type RoundEnded struct {
+	GameId    int
+	Round     int
+	Pot       int
+	PlayerIds []string
+}
+
+// FourKindWin is published when a single four a kind wins the final round regardless of final scores.
+type FourKindWin struct {
+	GameId   int
+	PlayerId string
+	Pot      int
+}
+
+// PotBombed is published when the top final scores tie and the pot carries to the next game.
+type PotBombed struct {
+	GameId    int
+	Pot       int
+	PlayerIds []string
+}
+
+// PotShared is published when tied players split a pot, followed by a WinnerPaid for each share.
+type PotShared struct {
+	GameId    int
+	Pot       int
+	PlayerIds []string // in the order the odd chips go.
+	Forced    bool     // split because too many pots in a row were carried over.
+}
+
+// TieBreakNeeded is published when the top final scores tie and only the tied players play the next game for the pot.
+type TieBreakNeeded struct {
+	GameId    int
+	Pot       int
+	PlayerIds []string
+}
+
+// AllIn is published when a player puts their last points into the pot, with the points they put in.
+// An all-in player stays in the game without being asked again and can only win the pots they have matched.
+type AllIn struct {
+	GameId   int
+	Round    int // 0 when the player couldn't cover the base point.
+	PlayerId string
+	Points   int
+}
+
+// SidePot is a share of the pot which only some of the players still in the game can win.
+type SidePot struct {
+	Pot       int
+	PlayerIds []string // the players who put in enough to win it.
+}
+
+// PotSplit is published before the final comparison when an all-in player splits the pot into side pots, main pot first.
+// Each side pot is then won, or bombed, on its own.
+type PotSplit struct {
+	GameId int
+	Pots   []SidePot
+}
+
+// WinnerPaid is published when the winner receives the pot, or one side pot of it; Points is the winner's points afterwards.
+type WinnerPaid struct {
+	GameId   int
+	PlayerId string
+	Pot      int
+	Points   int
+}
+
+func (GameStarted) Kind() string    { return "GameStarted" }
+func (CardDealt) Kind() string      { return "CardDealt" }
+func (Called) Kind() string         { return "Called" }
+func (CallRejected) Kind() string   { return "CallRejected" }
+func (InOrOutDecided) Kind() string { return "InOrOutDecided" }
+func (PlayerFolded) Kind() string   { return "PlayerFolded" }
+func (RoundEnded) Kind() string     { return "RoundEnded" }
+func (FourKindWin) Kind() string    { return "FourKindWin" }
+func (PotBombed) Kind() string      { return "PotBombed" }
+func (WinnerPaid) Kind() string     { return "WinnerPaid" }
+func (AllIn) Kind() string          { return "AllIn" }
+func (PotSplit) Kind() string       { return "PotSplit" }
+func (PotShared) Kind() string      { return "PotShared" }
+func (TieBreakNeeded) Kind() string { return "TieBreakNeeded" }
+
+func playerIds(players []*Player) []string {
+	ids := make([]string, len(players))
+	for i, p := range players {
+		ids[i] = p.id
+	}
+	return ids
+}
@@ -0,0 +1,76 @@
+package douji
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestRunPublishesEvents(t *testing.T) {
+	players := getFourTestingPlayers()
+	cardDealer, mg := getStubs(players, gomock.NewController(t))
+	game := NewGame(0, players, 1, 1, 0, 1, 5, nil, DefaultRules())
+	log := &EventLog{}
+	game.SetEventSink(log)
+	game.run(false, mg, cardDealer)
+
+	events := log.Events()
+	counts := map[string]int{}
+	for _, e := range events {
+		counts[e.Kind()]++
+	}
+	for kind, want := range map[string]int{
+		"GameStarted":    1,
+		"CardDealt":      17,
+		"Called":         4,
+		"InOrOutDecided": 9,
+		"PlayerFolded":   2,
+		"RoundEnded":     4,
+		"WinnerPaid":     1,
+		"FourKindWin":    0,
+		"PotBombed":      0,
+	} {
+		if counts[kind] != want {
+			t.Errorf("expected %d %s events but got:%d", want, kind, counts[kind])
+		}
+	}
+
+	started, ok := events[0].(GameStarted)
+	if !ok {
+		t.Fatalf("expected the first event to be GameStarted but got:%s", events[0].Kind())
+	}
+	if len(started.Players) != 4 || started.Players[0].Points != 100 {
+		t.Errorf("expected GameStarted to record 4 players with points before paying base but got:%v", started.Players)
+	}
+	paid, ok := events[len(events)-1].(WinnerPaid)
+	if !ok {
+		t.Fatalf("expected the last event to be WinnerPaid but got:%s", events[len(events)-1].Kind())
+	}
+	if paid.PlayerId != "0" || paid.Pot != 17 || paid.Points != 111 {
+		t.Errorf("expected Liu to be paid a pot of 17 ending with 111 points but got:%+v", paid)
+	}
+}
+
+func TestRunEventsBalancePoints(t *testing.T) {
+	players := getFourTestingPlayers()
+	cardDealer, mg := getStubs(players, gomock.NewController(t))
+	game := NewGame(0, players, 1, 1, 0, 1, 5, nil, DefaultRules())
+	pot := 0
+	game.SetEventSink(EventSinkFunc(func(e Event) {
+		switch ev := e.(type) {
+		case GameStarted:
+			pot = ev.Pot + ev.Base*len(ev.Players)
+		case Called:
+			pot += ev.Points
+		case InOrOutDecided:
+			if ev.In {
+				pot += ev.Points
+			}
+		case RoundEnded:
+			if ev.Pot != pot {
+				t.Errorf("round %d: expected pot %d from events but game has:%d", ev.Round, pot, ev.Pot)
+			}
+		}
+	}))
+	game.run(false, mg, cardDealer)
+}
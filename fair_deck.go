@@ -0,0 +1,272 @@
+package douji
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+var (
+	// ErrDealingStarted is returned when a client seed is committed or revealed after the first card has been dealt.
+	ErrDealingStarted = errors.New("fair deck has started dealing")
+	// ErrSeedsRevealing is returned when a client seed is committed after the first one has been revealed.
+	ErrSeedsRevealing = errors.New("client seeds are being revealed")
+	// ErrUncommittedSeed is returned when a revealed client seed matches no commitment waiting for it.
+	ErrUncommittedSeed = errors.New("client seed wasn't committed")
+	// ErrSeedsPending is returned when dealing while some committed client seeds haven't been revealed.
+	ErrSeedsPending = errors.New("client seeds not revealed")
+)
+
+// FairDeck is a provably fair CardDealer using commit-reveal.
+// The server shuffles the deck with a secret server seed and publishes a commitment to the seed and the shuffled order before dealing.
+// Players then contribute client seeds which cut the committed order into the final dealing order, so neither the server nor any player alone can choose the cards.
+// Client seeds are committed to first and only revealed once every commitment is in, so nobody, the server playing a player included,
+// can pick a seed after seeing the others. The game can't start until every committed seed is revealed.
+// After the game Reveal publishes everything needed by VerifyFairDeck.
+// A FairDeck deals a single 55 card deck, so a game played with more decks refuses to start with one with ErrWrongDecks.
+type FairDeck struct {
+	serverSeed        []byte
+	base              []Card // order shuffled by the server seed only; this is what the commitment covers.
+	commitment        string
+	clientCommitments []string
+	clientSeeds       [][]byte // in the order of clientCommitments, nil until revealed.
+	revealed          int
+	cards             []Card // final dealing order, set when the first card is dealt.
+	dealt             int
+}
+
+// FairDeckReveal is everything published after a game so anyone can verify a FairDeck.
+type FairDeckReveal struct {
+	ServerSeed        []byte
+	ClientCommitments []string // as published before any client seed was revealed.
+	ClientSeeds       [][]byte // in the order of ClientCommitments.
+	Order             []Card   // the final dealing order.
+}
+
+// NewFairDeck creates a FairDeck with a random server seed.
+func NewFairDeck() (*FairDeck, error) {
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, fmt.Errorf("failed to generate server seed:%w", err)
+	}
+	return NewFairDeckWithServerSeed(seed), nil
+}
+
+// NewFairDeckWithServerSeed creates a FairDeck from a given server seed which must be kept secret until the game is over.
+func NewFairDeckWithServerSeed(serverSeed []byte) *FairDeck {
+	base := shuffleBySeed(createCards(), serverSeed)
+	return &FairDeck{
+		serverSeed: append([]byte(nil), serverSeed...),
+		base:       base,
+		commitment: commitTo(serverSeed, base),
+	}
+}
+
+// Commitment returns the hex encoded SHA-256 of the server seed and the server shuffled order, to be published before dealing.
+func (d *FairDeck) Commitment() string {
+	return d.commitment
+}
+
+// ClientSeedCommitment returns the hex encoded SHA-256 of a client seed, which a player commits to before revealing the seed.
+func ClientSeedCommitment(seed []byte) string {
+	sum := sha256.Sum256(seed)
+	return hex.EncodeToString(sum[:])
+}
+
+// CommitClientSeed adds a player's commitment to their client seed, see ClientSeedCommitment.
+// Commitments can only be added before the first client seed is revealed.
+func (d *FairDeck) CommitClientSeed(commitment string) error {
+	switch {
+	case d.cards != nil:
+		return ErrDealingStarted
+	case d.revealed > 0:
+		return ErrSeedsRevealing
+	}
+	d.clientCommitments = append(d.clientCommitments, commitment)
+	d.clientSeeds = append(d.clientSeeds, nil)
+	return nil
+}
+
+// AddClientSeed reveals a committed client seed, adding it into the final dealing order.
+// Seeds can only be revealed before the first card is dealt.
+func (d *FairDeck) AddClientSeed(seed []byte) error {
+	if d.cards != nil {
+		return ErrDealingStarted
+	}
+	commitment := ClientSeedCommitment(seed)
+	for i, c := range d.clientCommitments {
+		if c == commitment && d.clientSeeds[i] == nil {
+			d.clientSeeds[i] = append([]byte{}, seed...)
+			d.revealed++
+			return nil
+		}
+	}
+	return ErrUncommittedSeed
+}
+
+// Ready returns ErrSeedsPending until every committed client seed is revealed; the game doesn't start before.
+func (d *FairDeck) Ready() error {
+	if pending := len(d.clientCommitments) - d.revealed; pending > 0 {
+		return fmt.Errorf("%w: %d of %d", ErrSeedsPending, pending, len(d.clientCommitments))
+	}
+	return nil
+}
+
+// Decks returns 1, a FairDeck deals a single deck.
+func (d *FairDeck) Decks() int {
+	return 1
+}
+
+// Remaining returns the number of cards left to deal from the single deck.
+func (d *FairDeck) Remaining() int {
+	return len(d.base) - d.dealt
+}
+
+// DealOne deals one card from the final dealing order.
+func (d *FairDeck) DealOne() Card {
+	if d.cards == nil {
+		if err := d.Ready(); err != nil {
+			panic(err)
+		}
+		d.cards = finalOrder(d.base, d.clientSeeds)
+	}
+	if d.dealt == len(d.cards) {
+		panic("empty deck, can't deal anymore")
+	}
+	c := d.cards[d.dealt]
+	d.dealt++
+	return c
+}
+
+// Reveal returns the server seed, client commitments and seeds and final order. It shall only be published once the game is over.
+func (d *FairDeck) Reveal() FairDeckReveal {
+	order := d.cards
+	if order == nil {
+		order = finalOrder(d.base, d.clientSeeds)
+	}
+	return FairDeckReveal{
+		ServerSeed:        append([]byte(nil), d.serverSeed...),
+		ClientCommitments: append([]string(nil), d.clientCommitments...),
+		ClientSeeds:       append([][]byte(nil), d.clientSeeds...),
+		Order:             append([]Card(nil), order...),
+	}
+}
+
+// DealtCards returns the cards dealt in a game in dealing order, e.g. to verify a FairDeck from a game's event log.
+func DealtCards(events []Event) []Card {
+	var cards []Card
+	for _, e := range events {
+		if cd, ok := e.(CardDealt); ok {
+			cards = append(cards, cd.Card)
+		}
+	}
+	return cards
+}
+
+// VerifyFairDeck checks a revealed deck against the commitment published before dealing, each client seed against its commitment,
+// and that dealt, in order, is exactly the start of the revealed order.
+func VerifyFairDeck(commitment string, r FairDeckReveal, dealt []Card) error {
+	base := shuffleBySeed(createCards(), r.ServerSeed)
+	if commitTo(r.ServerSeed, base) != commitment {
+		return errors.New("server seed doesn't match the commitment")
+	}
+	if len(r.ClientSeeds) != len(r.ClientCommitments) {
+		return fmt.Errorf("%d client seeds revealed but %d were committed", len(r.ClientSeeds), len(r.ClientCommitments))
+	}
+	for i, s := range r.ClientSeeds {
+		if ClientSeedCommitment(s) != r.ClientCommitments[i] {
+			return fmt.Errorf("client seed %d doesn't match its commitment", i)
+		}
+	}
+	order := finalOrder(base, r.ClientSeeds)
+	if len(order) != len(r.Order) {
+		return fmt.Errorf("revealed order has %d cards but expected %d", len(r.Order), len(order))
+	}
+	for i := range order {
+		if !isCard(order[i], r.Order[i]) {
+			return fmt.Errorf("revealed order differs from the seeds at card %d", i)
+		}
+	}
+	if len(dealt) > len(order) {
+		return fmt.Errorf("%d cards dealt from a deck of %d", len(dealt), len(order))
+	}
+	for i, c := range dealt {
+		if !isCard(c, order[i]) {
+			return fmt.Errorf("card %d dealt %s but the committed order has %s", i, c, order[i])
+		}
+	}
+	return nil
+}
+
+func commitTo(serverSeed []byte, order []Card) string {
+	h := sha256.New()
+	h.Write(serverSeed)
+	h.Write([]byte(encodeOrder(order)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func encodeOrder(cards []Card) string {
+	s := make([]string, len(cards))
+	for i, c := range cards {
+		s[i] = c.String()
+	}
+	return strings.Join(s, ",")
+}
+
+// finalOrder shuffles the committed order by all client seeds. Each seed is length prefixed so seeds can't be split or merged.
+func finalOrder(base []Card, clientSeeds [][]byte) []Card {
+	key := sha256.New()
+	for _, s := range clientSeeds {
+		var n [8]byte
+		binary.BigEndian.PutUint64(n[:], uint64(len(s)))
+		key.Write(n[:])
+		key.Write(s)
+	}
+	return shuffleBySeed(append([]Card(nil), base...), key.Sum(nil))
+}
+
+// shuffleBySeed does an unbiased Fisher-Yates shuffle of cards in place, drawing randomness from HMAC-SHA256 keyed by the seed in counter mode.
+func shuffleBySeed(cards []Card, seed []byte) []Card {
+	s := &seedStream{mac: hmac.New(sha256.New, seed)}
+	for i := len(cards) - 1; i > 0; i-- {
+		j := s.intn(i + 1)
+		cards[i], cards[j] = cards[j], cards[i]
+	}
+	return cards
+}
+
+type seedStream struct {
+	mac     hash.Hash
+	counter uint64
+	buf     []byte
+}
+
+func (s *seedStream) uint32() uint32 {
+	if len(s.buf) < 4 {
+		var c [8]byte
+		binary.BigEndian.PutUint64(c[:], s.counter)
+		s.counter++
+		s.mac.Reset()
+		s.mac.Write(c[:])
+		s.buf = s.mac.Sum(nil)
+	}
+	v := binary.BigEndian.Uint32(s.buf)
+	s.buf = s.buf[4:]
+	return v
+}
+
+// intn returns a uniform int in [0, n) by rejecting values from the biased tail.
+func (s *seedStream) intn(n int) int {
+	max := 1<<32 - (1<<32)%uint64(n)
+	for {
+		if v := uint64(s.uint32()); v < max {
+			return int(v % uint64(n))
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package douji
+
+import (
+	"errors"
+	"testing"
+)
+
+// commitAndReveal commits every seed to d before revealing any of them.
+func commitAndReveal(t *testing.T, d *FairDeck, seeds ...string) {
+	for _, seed := range seeds {
+		if err := d.CommitClientSeed(ClientSeedCommitment([]byte(seed))); err != nil {
+			t.Fatalf("expected to commit a client seed before revealing but got:%v", err)
+		}
+	}
+	for _, seed := range seeds {
+		if err := d.AddClientSeed([]byte(seed)); err != nil {
+			t.Fatalf("expected to reveal a committed client seed but got:%v", err)
+		}
+	}
+}
+
+func dealFairGame(t *testing.T, d *FairDeck) []Event {
+	players := getFourTestingPlayers()
+	game := NewGame(0, players, 1, 2, 0, 1, 5, nil, DefaultRules())
+	log := &EventLog{}
+	game.SetEventSink(log)
+	game.run(false, alwaysInMiddleGame{}, d)
+	return log.Events()
+}
+
+func TestFairDeckVerifies(t *testing.T) {
+	d, err := NewFairDeck()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitment := d.Commitment()
+	commitAndReveal(t, d, "Liu", "Wang", "Gu")
+	events := dealFairGame(t, d)
+	if err := d.CommitClientSeed(ClientSeedCommitment([]byte("Sun"))); err != ErrDealingStarted {
+		t.Errorf("expected ErrDealingStarted committing a seed after dealing but got:%v", err)
+	}
+	reveal := d.Reveal()
+	if len(reveal.Order) != 55 {
+		t.Fatalf("expected 55 cards in the revealed order but got:%d", len(reveal.Order))
+	}
+	if err := VerifyFairDeck(commitment, reveal, DealtCards(events)); err != nil {
+		t.Errorf("expected a fair deck to verify but got:%v", err)
+	}
+}
+
+func TestFairDeckDetectsCheating(t *testing.T) {
+	d := NewFairDeckWithServerSeed([]byte("server seed"))
+	commitAndReveal(t, d, "Liu")
+	commitment := d.Commitment()
+	dealt := DealtCards(dealFairGame(t, d))
+	reveal := d.Reveal()
+
+	swapped := append([]Card(nil), dealt...)
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+	if err := VerifyFairDeck(commitment, reveal, swapped); err == nil {
+		t.Errorf("expected dealing out of the committed order to fail verification.")
+	}
+
+	otherSeed := reveal
+	otherSeed.ServerSeed = []byte("another server seed")
+	if err := VerifyFairDeck(commitment, otherSeed, dealt); err == nil {
+		t.Errorf("expected a server seed not matching the commitment to fail verification.")
+	}
+
+	dropped := reveal
+	dropped.ClientSeeds = nil
+	dropped.Order = finalOrder(shuffleBySeed(createCards(), reveal.ServerSeed), nil)
+	if err := VerifyFairDeck(commitment, dropped, dealt); err == nil {
+		t.Errorf("expected dropping a client seed to fail verification.")
+	}
+
+	changed := reveal
+	changed.ClientSeeds = [][]byte{[]byte("Wang")}
+	changed.Order = finalOrder(shuffleBySeed(createCards(), reveal.ServerSeed), changed.ClientSeeds)
+	if err := VerifyFairDeck(commitment, changed, changed.Order[:len(dealt)]); err == nil {
+		t.Errorf("expected a client seed not matching its commitment to fail verification.")
+	}
+}
+
+func TestFairDeckClientSeedsChangeOrder(t *testing.T) {
+	d1 := NewFairDeckWithServerSeed([]byte("server seed"))
+	d2 := NewFairDeckWithServerSeed([]byte("server seed"))
+	if d1.Commitment() != d2.Commitment() {
+		t.Fatalf("expected the same server seed to give the same commitment.")
+	}
+	commitAndReveal(t, d2, "Liu")
+	same := true
+	for i := 0; i < 10; i++ {
+		if !isCard(d1.DealOne(), d2.DealOne()) {
+			same = false
+		}
+	}
+	if same {
+		t.Errorf("expected a client seed to change the dealing order.")
+	}
+}
+
+func TestFairDeckClientSeedsCommitBeforeReveal(t *testing.T) {
+	d := NewFairDeckWithServerSeed([]byte("server seed"))
+	if err := d.AddClientSeed([]byte("Liu")); err != ErrUncommittedSeed {
+		t.Errorf("expected ErrUncommittedSeed revealing a seed without a commitment but got:%v", err)
+	}
+	d.CommitClientSeed(ClientSeedCommitment([]byte("Liu")))
+	d.CommitClientSeed(ClientSeedCommitment([]byte("Wang")))
+	if err := d.AddClientSeed([]byte("Liu")); err != nil {
+		t.Fatalf("expected to reveal a committed seed but got:%v", err)
+	}
+	if err := d.AddClientSeed([]byte("Liu")); err != ErrUncommittedSeed {
+		t.Errorf("expected ErrUncommittedSeed revealing a seed twice but got:%v", err)
+	}
+	if err := d.CommitClientSeed(ClientSeedCommitment([]byte("Gu"))); err != ErrSeedsRevealing {
+		t.Errorf("expected ErrSeedsRevealing committing a seed after one was revealed but got:%v", err)
+	}
+
+	game := NewGame(0, getFourTestingPlayers(), 1, 2, 0, 1, 5, nil, DefaultRules())
+	if err := game.Start(d); !errors.Is(err, ErrSeedsPending) {
+		t.Errorf("expected the game to wait for Wang's seed but got:%v", err)
+	}
+	if err := d.AddClientSeed([]byte("Wang")); err != nil {
+		t.Fatalf("expected to reveal a committed seed but got:%v", err)
+	}
+	if err := game.Start(d); err != nil {
+		t.Errorf("expected the game to start once every seed is revealed but got:%v", err)
+	}
+}
+
+func TestFairDeckDealsOneDeck(t *testing.T) {
+	rules := DefaultRules()
+	rules.Decks = 2
+	game := NewGame(0, getFourTestingPlayers(), 1, 2, 0, 1, 5, nil, rules)
+	if err := game.Start(NewFairDeckWithServerSeed([]byte("server seed"))); !errors.Is(err, ErrWrongDecks) {
+		t.Errorf("expected a single fair deck not to deal a two deck game but got:%v", err)
+	}
+}
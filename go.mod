@@ -3,6 +3,7 @@ module douji
 go 1.16
 
 require (
-	github.com/golang/mock v1.5.0 // indirect
-	github.com/leancloud/go-sdk v0.1.0 // indirect
+	github.com/golang/mock v1.5.0
+	github.com/leancloud/go-sdk v0.1.0
+	golang.org/x/net v0.0.0-20190620200207-3b0461eec859
 )
@@ -0,0 +1,131 @@
+package douji
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// JournalEntry is a player's stats after a game, waiting in a Journal to be saved to LeanCloud.
+type JournalEntry struct {
+	Key      string    `json:"key"` // the StatsKey, so a row is never saved to LeanCloud twice.
+	SetId    string    `json:"set_id"`
+	GameId   int       `json:"game_id"`
+	Seed     int64     `json:"seed"`
+	Pot      PotStats  `json:"pot"`
+	Player   PlayerDTO `json:"player"`
+	Expected *int      `json:"expected,omitempty"` // the player's points in LeanCloud the game started from, when known.
+	// a player created while LeanCloud was unreachable, to be signed up before their rows are saved.
+	SignUp   bool   `json:"sign_up,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// DefaultJournalPath is the journal file in the working directory.
+const DefaultJournalPath = "douji_journal.jsonl"
+
+// Journal is a file of game stats which couldn't be saved to LeanCloud, one JSON entry per line in the order they were played.
+// Every entry is synced to disk before it's appended, so the stats survive the process until Sync saves them to LeanCloud.
+type Journal struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewJournal keeps the journal in the file at path, which is created on the first entry.
+func NewJournal(path string) *Journal {
+	return &Journal{path: path}
+}
+
+// Entries returns the entries waiting to be saved.
+func (j *Journal) Entries() ([]JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.read()
+}
+
+// pending reports whether any entry waits to be saved, in which case later stats go to the journal too so they stay in order.
+func (j *Journal) pending() (bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	info, err := os.Stat(j.path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil && info.Size() > 0, err
+}
+
+func (j *Journal) append(entries []JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(encodeEntries(entries))
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("journaling %d stats: %w", len(entries), err)
+	}
+	return nil
+}
+
+// update replaces the entries with the ones fn keeps, even when fn fails part way.
+// The file is replaced in one rename so a crash leaves either the old entries or the new ones.
+func (j *Journal) update(fn func([]JournalEntry) ([]JournalEntry, error)) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entries, err := j.read()
+	if err != nil {
+		return err
+	}
+	kept, fnErr := fn(entries)
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, encodeEntries(kept), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return err
+	}
+	return fnErr
+}
+
+// read reads every entry, ignoring a last line cut short by a crash while it was appended.
+func (j *Journal) read() ([]JournalEntry, error) {
+	b, err := os.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if i := bytes.LastIndexByte(b, '\n'); i < len(b)-1 {
+		b = b[:i+1]
+	}
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(nil, len(b)+1)
+	for line := 1; scanner.Scan(); line++ {
+		var e JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("reading line %d of %s: %w", line, j.path, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func encodeEntries(entries []JournalEntry) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		enc.Encode(e) // plain values always encode.
+	}
+	return buf.Bytes()
+}
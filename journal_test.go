@@ -0,0 +1,35 @@
+package douji
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestJournalSurvivesCutShortAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j := NewJournal(path)
+	if pending, err := j.pending(); pending || err != nil {
+		t.Errorf("expected nothing pending before the first entry but got:%t, %v", pending, err)
+	}
+	points := 100
+	want := []JournalEntry{
+		{Key: StatsKey("s", 1, "1"), SetId: "s", GameId: 1, Player: PlayerDTO{"1", "Liu", 90}, Expected: &points},
+		{Key: StatsKey("s", 1, "2"), SetId: "s", GameId: 1, Player: PlayerDTO{"2", "Wang", 110}},
+	}
+	if err := j.append(want); err != nil {
+		t.Fatal(err)
+	}
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	file.WriteString(`{"key":"s/2/1","set_`) // the process died while appending.
+	file.Close()
+
+	got, err := NewJournal(path).Entries()
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the complete entries %v but got:%v, %v", want, got, err)
+	}
+	if pending, _ := j.pending(); !pending {
+		t.Errorf("expected entries to be pending.")
+	}
+}
@@ -1,6 +1,11 @@
 package douji
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
 	"github.com/leancloud/go-sdk/leancloud"
 )
 
@@ -11,20 +16,74 @@ type GameStats struct {
 	Name     string `json:"player_name"`
 	PlayerId string `json:"player_id"`
 	Points   int    `json:"points"`
+	Seed     int64  `json:"seed"`
+	Key      string `json:"stats_key"` // the StatsKey, so journaled stats are never synced twice.
+	// what happened to the game's pot, see PotStats.
+	CarriedPot  int  `json:"carried_pot"`
+	Bombs       int  `json:"bombs"`
+	Contributed bool `json:"contributed"`
+	Rejoining   bool `json:"rejoining"`
+	ForcedSplit bool `json:"forced_split"`
 }
 
 // LeanCloudDB is a wrapper of LeanCloud which is a serverless cloud provider.
+// With a journal, stats which can't be saved to LeanCloud are journaled and saved later by Sync.
 type LeanCloudDB struct {
-	client *leancloud.Client
+	client  *leancloud.Client
+	journal *Journal
+	known   *balances
 }
 
 func NewLeanCloudDB() LeanCloudDB {
-	client := leancloud.NewEnvClient()
-	return LeanCloudDB{client: client}
+	return newLeanCloudDB(leancloud.NewEnvClient())
+}
+
+func newLeanCloudDB(client *leancloud.Client) LeanCloudDB {
+	return LeanCloudDB{client: client, known: &balances{points: map[string]int{}}}
+}
+
+// WithJournal returns a LeanCloudDB which journals the stats it can't save to LeanCloud instead of failing.
+func (lc LeanCloudDB) WithJournal(j *Journal) LeanCloudDB {
+	lc.journal = j
+	return lc
+}
+
+// balances are the players' points as last seen by a LeanCloudDB, which journaled games are checked against when they're synced.
+type balances struct {
+	mu     sync.Mutex
+	points map[string]int
+}
+
+func (b *balances) get(name string) *int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if points, ok := b.points[name]; ok {
+		return &points
+	}
+	return nil
+}
+
+func (b *balances) set(name string, points int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.points[name] = points
+}
+
+// requests serializes requests to LeanCloud, which the SDK counts in a package variable without any lock.
+var requests sync.Mutex
+
+func request(fn func() error) error {
+	requests.Lock()
+	defer requests.Unlock()
+	return fn()
 }
 
 func (lc LeanCloudDB) SaveSet(s *Set) error {
-	or, err := lc.client.Class(set).Create(s)
+	var or *leancloud.ObjectRef
+	err := request(func() (err error) {
+		or, err = lc.client.Class(set).Create(s)
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -32,25 +91,89 @@ func (lc LeanCloudDB) SaveSet(s *Set) error {
 	return nil
 }
 
+// CreatePlayer signs the player up and saves their starting points as game 0 of no set, so they can be loaded before they play.
+// With a journal, a player who can't be signed up because LeanCloud is unreachable is journaled with a local id and signed up by Sync.
 func (lc LeanCloudDB) CreatePlayer(name string, password string, points int) (string, error) {
-	player, err := lc.client.Users.SignUp(name, password)
+	player, err := lc.signUp(name, password)
+	if err != nil && lc.journal != nil && unreachable(err) {
+		id := localPlayerId(name)
+		e := JournalEntry{Key: StatsKey("", 0, id), SignUp: true, Password: password, Player: PlayerDTO{id, name, points}}
+		if err := lc.journal.append([]JournalEntry{e}); err != nil {
+			return "", fmt.Errorf("journaling player %s: %w", name, err)
+		}
+		return id, nil
+	}
 	if err != nil {
-		panic(err)
+		return "", err
 	}
 
-	err = lc.client.User(player).Set("points", points, leancloud.UseUser(player))
-	if err != nil {
-		panic(err)
+	if err := lc.setPoints(player, name, points); err != nil {
+		return "", err
+	}
+	if err := lc.create(JournalEntry{Key: StatsKey("", 0, player.ID), Player: PlayerDTO{player.ID, name, points}}); err != nil {
+		return "", fmt.Errorf("saving starting points of player %s: %w", name, err)
 	}
+	lc.known.set(name, points)
 	return player.ID, nil
 }
 
-func (lc LeanCloudDB) LoadPlayerStatsByName(name string) *Player {
-	ret := []PlayerDTO{}
-	if err := lc.client.Class(gameStatsClass).NewQuery().EqualTo("player_name", name).Order("-createdAt").Find(&ret); err != nil {
-		panic(err)
+func (lc LeanCloudDB) signUp(name string, password string) (*leancloud.User, error) {
+	var player *leancloud.User
+	err := request(func() (err error) {
+		player, err = lc.client.Users.SignUp(name, password)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("signing up player %s: %w", name, err)
 	}
-	return &Player{Name: name, points: ret[0].Points, id: ret[0].Id}
+	return player, nil
+}
+
+func (lc LeanCloudDB) setPoints(player *leancloud.User, name string, points int) error {
+	err := request(func() error { return lc.client.User(player).Set("points", points, leancloud.UseUser(player)) })
+	if err != nil {
+		return fmt.Errorf("setting points of player %s: %w", name, err)
+	}
+	return nil
+}
+
+// localPlayerId is the id of a player created while LeanCloud was unreachable, until Sync signs them up.
+func localPlayerId(name string) string {
+	return "local-" + name
+}
+
+// unreachable reports whether err means LeanCloud couldn't be reached rather than that it refused the request, e.g. for a taken name.
+func unreachable(err error) bool {
+	var resp *leancloud.ServerResponseError
+	return !errors.As(err, &resp) || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// LoadPlayerStatsByName loads a player with their latest points, which are the journaled ones while the journal has any.
+// With a journal, a player who isn't journaled is reported as ErrPlayerNotFound while LeanCloud is unreachable, so they can be created offline.
+func (lc LeanCloudDB) LoadPlayerStatsByName(name string) (*Player, error) {
+	if lc.journal != nil {
+		entries, err := lc.journal.Entries()
+		if err != nil {
+			return nil, err
+		}
+		for i := len(entries) - 1; i >= 0; i-- {
+			if p := entries[i].Player; p.Name == name {
+				return &Player{Name: name, points: p.Points, id: p.Id}, nil
+			}
+		}
+	}
+	latest, err := lc.latest(name)
+	if err != nil && lc.journal != nil && unreachable(err) {
+		return nil, fmt.Errorf("%w: %s isn't journaled and LeanCloud is unreachable: %v", ErrPlayerNotFound, name, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading player %s: %w", name, err)
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPlayerNotFound, name)
+	}
+	lc.known.set(name, latest.Points)
+	return &Player{Name: name, points: latest.Points, id: latest.Id}, nil
 }
 
 const (
@@ -59,12 +182,238 @@ const (
 	// player         = "Player"
 )
 
-func (lc LeanCloudDB) SaveGameStats(setId string, gameId int, pnp []PlayerDTO) error {
-	for _, p := range pnp {
-		gs := GameStats{SetId: setId, GameId: gameId, Name: p.Name, Points: p.Points, PlayerId: p.Id}
-		if _, err := lc.client.Class(gameStatsClass).Create(&gs); err != nil {
-			panic(err)
+// SaveGameStats saves a row for every player. With a journal, the rows which can't be saved are journaled instead,
+// and so is every row after them until the journal is synced, so rows reach LeanCloud in the order games were played.
+func (lc LeanCloudDB) SaveGameStats(setId string, gameId int, seed int64, pot PotStats, pnp []PlayerDTO) error {
+	entries := make([]JournalEntry, len(pnp))
+	for i, p := range pnp {
+		entries[i] = JournalEntry{Key: StatsKey(setId, gameId, p.Id), SetId: setId, GameId: gameId, Seed: seed, Pot: pot, Player: p}
+	}
+	if lc.journal != nil {
+		if pending, err := lc.journal.pending(); err != nil || pending {
+			return lc.journalFrom(entries, err)
+		}
+	}
+	for i, e := range entries {
+		if err := lc.createOnce(e); err != nil {
+			if lc.journal == nil {
+				return fmt.Errorf("saving stats of player %s: %w", e.Player.Name, err)
+			}
+			return lc.journalFrom(entries[i:], err)
+		}
+		lc.known.set(e.Player.Name, e.Player.Points)
+	}
+	return nil
+}
+
+// journalFrom journals entries which couldn't be saved to LeanCloud because of cause, if any.
+func (lc LeanCloudDB) journalFrom(entries []JournalEntry, cause error) error {
+	for i := range entries {
+		entries[i].Expected = lc.known.get(entries[i].Player.Name)
+		lc.known.set(entries[i].Player.Name, entries[i].Player.Points)
+	}
+	if err := lc.journal.append(entries); err != nil {
+		if cause != nil {
+			return fmt.Errorf("journaling after %v: %w", cause, err)
 		}
+		return err
 	}
 	return nil
 }
+
+func (lc LeanCloudDB) create(e JournalEntry) error {
+	p, pot := e.Player, e.Pot
+	gs := GameStats{SetId: e.SetId, GameId: e.GameId, Name: p.Name, Points: p.Points, PlayerId: p.Id, Seed: e.Seed, Key: e.Key,
+		CarriedPot: pot.Carried, Bombs: pot.Bombs, Contributed: contains(pot.Contributors, p.Id), Rejoining: contains(pot.Rejoining, p.Id), ForcedSplit: pot.ForcedSplit}
+	return request(func() error {
+		_, err := lc.client.Class(gameStatsClass).Create(&gs)
+		return err
+	})
+}
+
+// createOnce saves a row unless a row with its StatsKey is already saved, e.g. by a request which timed out after storing it.
+func (lc LeanCloudDB) createOnce(e JournalEntry) error {
+	saved, err := lc.saved(e.Key)
+	if err != nil || saved {
+		return err
+	}
+	return lc.create(e)
+}
+
+// latest returns a player's latest stats in LeanCloud, nil when there are none.
+func (lc LeanCloudDB) latest(name string) (*PlayerDTO, error) {
+	ret := []PlayerDTO{}
+	if err := request(func() error {
+		return lc.client.Class(gameStatsClass).NewQuery().EqualTo("player_name", name).Order("-createdAt").Limit(1).Find(&ret)
+	}); err != nil {
+		return nil, err
+	}
+	if len(ret) == 0 {
+		return nil, nil
+	}
+	return &ret[0], nil
+}
+
+// saved reports whether the row of a StatsKey is in LeanCloud.
+func (lc LeanCloudDB) saved(key string) (bool, error) {
+	ret := []PlayerDTO{}
+	err := request(func() error {
+		return lc.client.Class(gameStatsClass).NewQuery().EqualTo("stats_key", key).Limit(1).Find(&ret)
+	})
+	return len(ret) > 0, err
+}
+
+// SyncReport sums up a sync of a journal to LeanCloud.
+type SyncReport struct {
+	Synced    int            // rows saved to LeanCloud.
+	Skipped   int            // rows already in LeanCloud, e.g. saved by a sync which was cut short.
+	Conflicts []SyncConflict // players whose rows are left in the journal.
+}
+
+// SyncConflict is a player whose points in LeanCloud changed since their first journaled game was played, e.g. by a session elsewhere.
+// Their rows are left in the journal to be sorted out by hand.
+type SyncConflict struct {
+	PlayerId   string
+	PlayerName string
+	Expected   int // the points the journaled games started from.
+	Actual     int // the latest points in LeanCloud.
+	Pending    int // rows left in the journal.
+}
+
+// ErrNoJournal is returned when syncing a LeanCloudDB without a journal.
+var ErrNoJournal = errors.New("no journal to sync")
+
+// Sync saves the journaled rows to LeanCloud player by player in the order they were played, removing them from the journal.
+// A player's rows are only saved when their latest points in LeanCloud are still the ones their first journaled game started from.
+// On an error, e.g. LeanCloud still being unreachable, the rows not saved yet are kept for the next sync.
+func (lc LeanCloudDB) Sync() (SyncReport, error) {
+	if lc.journal == nil {
+		return SyncReport{}, ErrNoJournal
+	}
+	var report SyncReport
+	err := lc.journal.update(func(entries []JournalEntry) ([]JournalEntry, error) {
+		done := make([]bool, len(entries))
+		var err error
+		for _, idx := range entriesByPlayer(entries) {
+			if err != nil {
+				break
+			}
+			if entries[idx[0]].SignUp {
+				var conflict *SyncConflict
+				if conflict, err = lc.signUpJournaled(entries, idx); conflict != nil {
+					report.Conflicts = append(report.Conflicts, *conflict)
+				}
+				if err != nil || conflict != nil {
+					continue
+				}
+			}
+			for len(idx) > 0 {
+				var saved bool
+				if saved, err = lc.saved(entries[idx[0]].Key); err != nil || !saved {
+					break
+				}
+				done[idx[0]] = true
+				report.Skipped++
+				idx = idx[1:]
+			}
+			if err != nil || len(idx) == 0 {
+				continue
+			}
+			if conflict, cerr := lc.conflict(entries[idx[0]], len(idx)); cerr != nil || conflict != nil {
+				err = cerr
+				if conflict != nil {
+					report.Conflicts = append(report.Conflicts, *conflict)
+				}
+				continue
+			}
+			for _, i := range idx {
+				if err = lc.create(entries[i]); err != nil {
+					break
+				}
+				done[i] = true
+				report.Synced++
+			}
+		}
+		var kept []JournalEntry
+		for i, e := range entries {
+			if !done[i] {
+				kept = append(kept, e)
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf("syncing the journal: %w", err)
+		}
+		return kept, err
+	})
+	return report, err
+}
+
+// signUpJournaled signs up a player created while LeanCloud was unreachable and gives their journaled rows the id LeanCloud assigned.
+// A player whose name was taken meanwhile, e.g. by a session elsewhere, is a conflict.
+func (lc LeanCloudDB) signUpJournaled(entries []JournalEntry, idx []int) (*SyncConflict, error) {
+	first := entries[idx[0]]
+	name := first.Player.Name
+	player, err := lc.signUp(name, first.Password)
+	if err != nil && unreachable(err) {
+		return nil, err
+	}
+	if err != nil {
+		latest, err := lc.latest(name)
+		if err != nil {
+			return nil, err
+		}
+		conflict := &SyncConflict{PlayerId: first.Player.Id, PlayerName: name, Expected: first.Player.Points, Pending: len(idx)}
+		if latest != nil {
+			conflict.Actual = latest.Points
+		}
+		return conflict, nil
+	}
+	for _, i := range idx {
+		e := &entries[i]
+		e.Key = StatsKey(e.SetId, e.GameId, player.ID)
+		e.Pot.Contributors = replaceId(e.Pot.Contributors, first.Player.Id, player.ID)
+		e.Pot.Rejoining = replaceId(e.Pot.Rejoining, first.Player.Id, player.ID)
+		e.Player.Id = player.ID
+		e.SignUp, e.Password = false, ""
+	}
+	return nil, lc.setPoints(player, name, first.Player.Points)
+}
+
+func replaceId(ids []string, old, new string) []string {
+	var replaced []string
+	for _, id := range ids {
+		if id == old {
+			id = new
+		}
+		replaced = append(replaced, id)
+	}
+	return replaced
+}
+
+// conflict returns a conflict when a player's latest points in LeanCloud aren't the ones their first journaled row expects.
+func (lc LeanCloudDB) conflict(first JournalEntry, pending int) (*SyncConflict, error) {
+	if first.Expected == nil {
+		return nil, nil
+	}
+	latest, err := lc.latest(first.Player.Name)
+	if err != nil || latest == nil || latest.Points == *first.Expected {
+		return nil, err
+	}
+	return &SyncConflict{PlayerId: first.Player.Id, PlayerName: first.Player.Name, Expected: *first.Expected, Actual: latest.Points, Pending: pending}, nil
+}
+
+// entriesByPlayer returns the indexes of every player's entries in order, players in the order of their first entry.
+func entriesByPlayer(entries []JournalEntry) [][]int {
+	var byPlayer [][]int
+	index := map[string]int{}
+	for i, e := range entries {
+		p, ok := index[e.Player.Name]
+		if !ok {
+			p = len(byPlayer)
+			index[e.Player.Name] = p
+			byPlayer = append(byPlayer, nil)
+		}
+		byPlayer[p] = append(byPlayer[p], i)
+	}
+	return byPlayer
+}
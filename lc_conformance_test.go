@@ -0,0 +1,12 @@
+package douji_test
+
+import (
+	"testing"
+
+	"douji"
+	"douji/dbtest"
+)
+
+func TestLeanCloudDBConformance(t *testing.T) {
+	dbtest.Run(t, douji.NewFakeLeanCloudDB)
+}
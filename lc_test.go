@@ -0,0 +1,287 @@
+package douji
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leancloud/go-sdk/leancloud"
+)
+
+// fakeLeanCloud serves the part of the LeanCloud REST API LeanCloudDB uses, keeping objects in memory.
+type fakeLeanCloud struct {
+	mu      sync.Mutex
+	objects map[string][]map[string]interface{} // objects by class, in the order they were created.
+	users   map[string]string                   // user ids by name.
+	down    bool                                // when set, every request fails as if LeanCloud was unreachable.
+	created int
+}
+
+func newFakeLeanCloud(t *testing.T) (*fakeLeanCloud, LeanCloudDB) {
+	f := &fakeLeanCloud{objects: map[string][]map[string]interface{}{}, users: map[string]string{}}
+	ts := httptest.NewServer(f)
+	t.Cleanup(ts.Close)
+	client := leancloud.NewClient(&leancloud.ClientOptions{AppID: "test", AppKey: "test", ServerURL: ts.URL})
+	return f, newLeanCloudDB(client)
+}
+
+// NewFakeLeanCloudDB returns a LeanCloudDB on a fake LeanCloud, for the Db conformance suite which runs from package douji_test.
+func NewFakeLeanCloudDB(t *testing.T) Db {
+	_, db := newFakeLeanCloud(t)
+	return db
+}
+
+// rows returns the GameStat rows saved with a stats key.
+func (f *fakeLeanCloud) rows(key string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, o := range f.objects[gameStatsClass] {
+		if o["stats_key"] == key {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeLeanCloud) setDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = down
+}
+
+func (f *fakeLeanCloud) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if f.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"code":503,"error":"unavailable"}`)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/1.1/")
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	switch {
+	case r.Method == http.MethodPost && path == "users":
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		name := body["username"].(string)
+		if _, ok := f.users[name]; ok {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":202,"error":"username has already been taken"}`)
+			return
+		}
+		f.created++
+		id := fmt.Sprint("user", f.created)
+		f.users[name] = id
+		json.NewEncoder(w).Encode(map[string]interface{}{"objectId": id, "createdAt": now, "sessionToken": "token"})
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "users/"):
+		json.NewEncoder(w).Encode(map[string]interface{}{"updatedAt": now})
+	case r.Method == http.MethodPost && strings.HasPrefix(path, "classes/"):
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.created++
+		body["objectId"], body["createdAt"], body["updatedAt"] = fmt.Sprint("obj", f.created), now, now
+		class := strings.TrimPrefix(path, "classes/")
+		f.objects[class] = append(f.objects[class], body)
+		json.NewEncoder(w).Encode(map[string]interface{}{"objectId": body["objectId"], "createdAt": now})
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "classes/"):
+		var where map[string]interface{}
+		json.Unmarshal([]byte(r.URL.Query().Get("where")), &where)
+		var results []map[string]interface{}
+		for _, o := range f.objects[strings.TrimPrefix(path, "classes/")] {
+			matched := true
+			for k, v := range where {
+				matched = matched && o[k] == v
+			}
+			if matched {
+				results = append(results, o)
+			}
+		}
+		if r.URL.Query().Get("order") == "-createdAt" {
+			for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+				results[i], results[j] = results[j], results[i]
+			}
+		}
+		if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit < len(results) {
+			results = results[:limit]
+		}
+		if results == nil {
+			results = []map[string]interface{}{}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"code":404,"error":"not found"}`)
+	}
+}
+
+func TestLeanCloudDBJournalsWhileDown(t *testing.T) {
+	f, db := newFakeLeanCloud(t)
+	liu, wang := createPlayer(t, db, "Liu", 100), createPlayer(t, db, "Wang", 100)
+	f.setDown(true)
+	if err := db.SaveGameStats("s", 1, 1, PotStats{}, []PlayerDTO{{liu, "Liu", 90}, {wang, "Wang", 110}}); err == nil {
+		t.Errorf("expected saving without a journal to fail while LeanCloud is down.")
+	}
+
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal"))
+	db = db.WithJournal(journal)
+	if err := db.SaveGameStats("s", 1, 1, PotStats{}, []PlayerDTO{{liu, "Liu", 90}, {wang, "Wang", 110}}); err != nil {
+		t.Fatalf("expected stats to be journaled while LeanCloud is down but got:%v", err)
+	}
+	f.setDown(false)
+	if err := db.SaveGameStats("s", 2, 1, PotStats{}, []PlayerDTO{{liu, "Liu", 95}, {wang, "Wang", 105}}); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := journal.Entries(); len(entries) != 4 || f.rows(StatsKey("s", 2, liu)) != 0 {
+		t.Errorf("expected later stats to be journaled behind the pending ones but got %d entries", len(entries))
+	}
+	if p, err := db.LoadPlayerStatsByName("Liu"); err != nil || p.points != 95 {
+		t.Errorf("expected Liu's journaled points but got:%v, %v", p, err)
+	}
+
+	report, err := db.Sync()
+	if err != nil || report.Synced != 4 || len(report.Conflicts) != 0 {
+		t.Fatalf("expected every journaled row to be synced but got:%+v, %v", report, err)
+	}
+	if entries, _ := journal.Entries(); len(entries) != 0 {
+		t.Errorf("expected an empty journal after syncing but got:%v", entries)
+	}
+	if latest, _ := db.latest("Wang"); latest.Points != 105 {
+		t.Errorf("expected Wang's last game to be the latest in LeanCloud but got:%d", latest.Points)
+	}
+	if report, _ := db.Sync(); report.Synced != 0 || f.rows(StatsKey("s", 1, liu)) != 1 {
+		t.Errorf("expected nothing left to sync but got:%+v", report)
+	}
+}
+
+func TestLeanCloudDBSyncConflict(t *testing.T) {
+	f, db := newFakeLeanCloud(t)
+	liu, wang := createPlayer(t, db, "Liu", 100), createPlayer(t, db, "Wang", 100)
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal"))
+	db = db.WithJournal(journal)
+	f.setDown(true)
+	db.SaveGameStats("s", 1, 1, PotStats{}, []PlayerDTO{{liu, "Liu", 90}, {wang, "Wang", 110}})
+	db.SaveGameStats("s", 2, 1, PotStats{}, []PlayerDTO{{liu, "Liu", 80}, {wang, "Wang", 120}})
+	f.setDown(false)
+	elsewhere := newLeanCloudDB(db.client) // another session on the same LeanCloud.
+	if err := elsewhere.SaveGameStats("other", 1, 1, PotStats{}, []PlayerDTO{{liu, "Liu", 130}}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := db.Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SyncConflict{{PlayerId: liu, PlayerName: "Liu", Expected: 100, Actual: 130, Pending: 2}}
+	if report.Synced != 2 || !reflect.DeepEqual(report.Conflicts, want) {
+		t.Errorf("expected Wang to be synced and Liu to conflict but got:%+v", report)
+	}
+	entries, _ := journal.Entries()
+	if len(entries) != 2 || entries[0].Key != StatsKey("s", 1, liu) || entries[1].Key != StatsKey("s", 2, liu) {
+		t.Errorf("expected Liu's rows to be left in the journal in order but got:%v", entries)
+	}
+}
+
+func TestLeanCloudDBSyncResumes(t *testing.T) {
+	f, db := newFakeLeanCloud(t)
+	liu := createPlayer(t, db, "Liu", 100)
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal"))
+	db = db.WithJournal(journal)
+	f.setDown(true)
+	for g := 1; g <= 3; g++ {
+		db.SaveGameStats("s", g, 1, PotStats{}, []PlayerDTO{{liu, "Liu", 100 - g}})
+	}
+	if _, err := db.Sync(); err == nil {
+		t.Errorf("expected syncing to fail while LeanCloud is down.")
+	}
+	if entries, _ := journal.Entries(); len(entries) != 3 {
+		t.Fatalf("expected a failed sync to keep every row but got:%v", entries)
+	}
+	f.setDown(false)
+	entries, _ := journal.Entries()
+	db.create(entries[0]) // a sync cut short after saving the first row.
+
+	report, err := db.Sync()
+	if err != nil || report.Skipped != 1 || report.Synced != 2 || len(report.Conflicts) != 0 {
+		t.Errorf("expected the saved row to be skipped and the others synced but got:%+v, %v", report, err)
+	}
+	if f.rows(StatsKey("s", 1, liu)) != 1 {
+		t.Errorf("expected the first row to be saved once.")
+	}
+}
+
+func TestLeanCloudDBCreatesPlayersWhileDown(t *testing.T) {
+	f, db := newFakeLeanCloud(t)
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal"))
+	db = db.WithJournal(journal)
+	f.setDown(true)
+	if _, err := db.LoadPlayerStatsByName("Gu"); !errors.Is(err, ErrPlayerNotFound) {
+		t.Fatalf("expected a player who isn't journaled to be not found while LeanCloud is down but got:%v", err)
+	}
+	local, err := db.CreatePlayer("Gu", "secret", 1000)
+	if err != nil {
+		t.Fatalf("expected the player to be created locally while LeanCloud is down but got:%v", err)
+	}
+	if p, err := db.LoadPlayerStatsByName("Gu"); err != nil || p.id != local || p.points != 1000 {
+		t.Fatalf("expected the journaled player but got:%v, %v", p, err)
+	}
+	db.SaveGameStats("s", 1, 1, PotStats{Contributors: []string{local}}, []PlayerDTO{{local, "Gu", 900}})
+	f.setDown(false)
+
+	report, err := db.Sync()
+	if err != nil || report.Synced != 2 || len(report.Conflicts) != 0 {
+		t.Fatalf("expected the player to be signed up and their rows synced but got:%+v, %v", report, err)
+	}
+	id := f.users["Gu"]
+	if id == "" || f.rows(StatsKey("", 0, id)) != 1 || f.rows(StatsKey("s", 1, id)) != 1 {
+		t.Errorf("expected the rows to be saved with the id LeanCloud assigned, %q", id)
+	}
+	if p, err := db.LoadPlayerStatsByName("Gu"); err != nil || p.id != id || p.points != 900 {
+		t.Errorf("expected Gu's synced points but got:%v, %v", p, err)
+	}
+	if o := f.objects[gameStatsClass][1]; o["contributed"] != true {
+		t.Errorf("expected Gu to still have contributed to the pot but got:%v", o)
+	}
+}
+
+func TestLeanCloudDBSyncConflictOfPlayerCreatedWhileDown(t *testing.T) {
+	f, db := newFakeLeanCloud(t)
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal"))
+	db = db.WithJournal(journal)
+	f.setDown(true)
+	local, _ := db.CreatePlayer("Sun", "secret", 1000)
+	f.setDown(false)
+	elsewhere := newLeanCloudDB(db.client)
+	createPlayer(t, elsewhere, "Sun", 500)
+
+	report, err := db.Sync()
+	want := []SyncConflict{{PlayerId: local, PlayerName: "Sun", Expected: 1000, Actual: 500, Pending: 1}}
+	if err != nil || !reflect.DeepEqual(report.Conflicts, want) {
+		t.Errorf("expected a conflict on the taken name but got:%+v, %v", report, err)
+	}
+	if entries, _ := journal.Entries(); len(entries) != 1 || !entries[0].SignUp {
+		t.Errorf("expected the player to be left in the journal but got:%v", entries)
+	}
+}
+
+func createPlayer(t *testing.T, db LeanCloudDB, name string, points int) string {
+	t.Helper()
+	id, err := db.CreatePlayer(name, "secret", points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.LoadPlayerStatsByName(name); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
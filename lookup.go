@@ -0,0 +1,183 @@
+package douji
+
+import (
+	"sync"
+)
+
+// handKey is the canonical encoding of a one deck hand: how many cards it holds of each of the 13 ranks, 3 bits each,
+// followed by one bit each for the wild card, the red joker, the black joker and the special card.
+// Scoring only depends on these counts, so every ordering and every choice of suits of the same hand shares a key.
+type handKey uint64
+
+const (
+	rankSlots  = 13 // 2 to 10, J, Q, K and A.
+	wildSlot   = rankSlots
+	jokerRSlot = rankSlots + 1
+	jokerBSlot = rankSlots + 2
+	specSlot   = rankSlots + 3
+	slots      = rankSlots + 4
+)
+
+// slotShift returns where a slot's count starts in a handKey.
+func slotShift(slot int) uint {
+	if slot < rankSlots {
+		return uint(3 * slot)
+	}
+	return uint(3*rankSlots + slot - rankSlots)
+}
+
+// slotOf returns the slot a card is counted in, false for cards not in a deck.
+func slotOf(c Card, wild Card) (int, bool) {
+	switch {
+	case isCard(c, wild):
+		return wildSlot, true
+	case isCard(c, jokerR):
+		return jokerRSlot, true
+	case isCard(c, jokerB):
+		return jokerBSlot, true
+	case c.rank == 21:
+		return specSlot, true
+	case c.rank >= 2 && c.rank <= 13:
+		return c.rank - 2, true
+	case c.rank == 15:
+		return 12, true
+	}
+	return 0, false
+}
+
+// slotMax returns how many cards of a slot one deck holds.
+func slotMax(slot int) int {
+	if slot < rankSlots {
+		return 4
+	}
+	return 1
+}
+
+// count returns how many cards of a slot the hand holds.
+func (k handKey) count(slot int) int {
+	mask := handKey(7)
+	if slot >= rankSlots {
+		mask = 1
+	}
+	return int(k >> slotShift(slot) & mask)
+}
+
+// ScoreTable holds the precomputed score of every 5 and 6 card hand of one deck under a rule set.
+type ScoreTable struct {
+	rules  RuleSet
+	scores map[handKey]uint16 // the total score, with fourKindBit set for four a kinds.
+}
+
+const fourKindBit = 1 << 15
+
+var (
+	scoreTablesMu sync.Mutex
+	scoreTables   = map[RuleSet]*ScoreTable{} // by the scoring rules only.
+)
+
+// scoreTableOf returns the score table of rules, generating it on first use; nil when rules play with more than one deck.
+func scoreTableOf(rules *RuleSet) *ScoreTable {
+	if rules.Decks != 1 {
+		return nil
+	}
+	key := rules.scoring()
+	scoreTablesMu.Lock()
+	defer scoreTablesMu.Unlock()
+	t, ok := scoreTables[key]
+	if !ok {
+		t = NewScoreTable(key)
+		scoreTables[key] = t
+	}
+	return t
+}
+
+// scoring returns only the rules Evaluate scores by, so rule sets which only differ in how the game is played share a score table.
+func (r RuleSet) scoring() RuleSet {
+	return RuleSet{
+		WildCard:       r.WildCard,
+		FiveKindScore:  r.FiveKindScore,
+		FourKindBonus:  r.FourKindBonus,
+		ThreeKindBonus: r.ThreeKindBonus,
+		JokerPairBonus: r.JokerPairBonus,
+	}
+}
+
+// NewScoreTable enumerates every multiset of 5 and 6 cards from one deck and scores each with rules.Evaluate.
+func NewScoreTable(rules RuleSet) *ScoreTable {
+	t := &ScoreTable{rules: rules, scores: map[handKey]uint16{}}
+	t.eachHand(func(key handKey, cards []Card) {
+		b := rules.Evaluate(cards)
+		score := uint16(b.Total)
+		if b.FourKind {
+			score |= fourKindBit
+		}
+		t.scores[key] = score
+	})
+	return t
+}
+
+// eachHand calls f with the key and a representative hand of every multiset of 5 and 6 cards from one deck.
+func (t *ScoreTable) eachHand(f func(key handKey, cards []Card)) {
+	bySlot := make([][]Card, slots)
+	for _, c := range createCards() {
+		slot, _ := slotOf(c, t.rules.WildCard)
+		bySlot[slot] = append(bySlot[slot], c)
+	}
+	cards := make([]Card, 0, 6)
+	var walk func(slot int, key handKey)
+	walk = func(slot int, key handKey) {
+		if len(cards) >= 5 {
+			f(key, cards)
+		}
+		if len(cards) == 6 {
+			return
+		}
+		for s := slot; s < slots; s++ {
+			used := key.count(s)
+			if used == len(bySlot[s]) {
+				continue
+			}
+			cards = append(cards, bySlot[s][used])
+			walk(s, key+1<<slotShift(s))
+			cards = cards[:len(cards)-1]
+		}
+	}
+	walk(0, 0)
+}
+
+// Len returns the number of hands in the table.
+func (t *ScoreTable) Len() int {
+	return len(t.scores)
+}
+
+// keyOf encodes hidden and public cards together, false when they aren't a hand of one deck.
+func (t *ScoreTable) keyOf(hidden, public []Card) (handKey, bool) {
+	var key handKey
+	for _, cards := range [2][]Card{hidden, public} {
+		for _, c := range cards {
+			slot, ok := slotOf(c, t.rules.WildCard)
+			if !ok || key.count(slot) == slotMax(slot) {
+				return 0, false
+			}
+			key += 1 << slotShift(slot)
+		}
+	}
+	return key, true
+}
+
+// Score looks up the total score of hidden and public cards together and whether they make a four a kind.
+// It returns false for hands not in the table, e.g. of fewer than 5 cards, which have to be evaluated instead.
+func (t *ScoreTable) Score(hidden, public []Card) (int, bool, bool) {
+	if n := len(hidden) + len(public); n < 5 || n > 6 {
+		return 0, false, false
+	}
+	key, ok := t.keyOf(hidden, public)
+	if !ok {
+		return 0, false, false
+	}
+	score, ok := t.scores[key]
+	if !ok {
+		return 0, false, false
+	}
+	return int(score &^ fourKindBit), score&fourKindBit != 0, true
+}
@@ -0,0 +1,89 @@
+package douji
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestScoreTableAgreesWithEvaluate(t *testing.T) {
+	rules := DefaultRules()
+	table := scoreTableOf(&rules)
+	hands := 0
+	table.eachHand(func(key handKey, cards []Card) {
+		hands++
+		b := rules.Evaluate(cards)
+		score, fourKind, ok := table.Score(cards[:1], cards[1:])
+		if !ok || score != b.Total || fourKind != b.FourKind {
+			t.Fatalf("%v: expected %d (four a kind:%v) but the table has %d (four a kind:%v, found:%v)", cards, b.Total, b.FourKind, score, fourKind, ok)
+		}
+	})
+	if hands != table.Len() {
+		t.Errorf("expected a table entry for each of the %d hands but got:%d", hands, table.Len())
+	}
+}
+
+func TestScoreTableKeysIgnoreOrderAndSuits(t *testing.T) {
+	rules := DefaultRules()
+	table := scoreTableOf(&rules)
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 20000; i++ {
+		cards := createCards()
+		r.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+		hand := cards[:5+r.Intn(2)]
+		hidden := 1 + r.Intn(2)
+		score, fourKind, ok := table.Score(hand[:hidden], hand[hidden:])
+		if b := rules.Evaluate(hand); !ok || score != b.Total || fourKind != b.FourKind {
+			t.Fatalf("%v: expected %d (four a kind:%v) but got %d (four a kind:%v, found:%v)", hand, b.Total, b.FourKind, score, fourKind, ok)
+		}
+	}
+}
+
+func TestScoreTableSkipsHandsOutsideTheDeck(t *testing.T) {
+	rules := DefaultRules()
+	table := scoreTableOf(&rules)
+	for _, hand := range [][]Card{
+		{{rank: 10}, {rank: 10}},
+		{{rank: 10}, {rank: 10}, {rank: 10}, {rank: 10}, {rank: 10}},
+		{wildCard, wildCard, {rank: 3}, {rank: 4}, {rank: 5}},
+		{{rank: 19}, {rank: 3}, {rank: 4}, {rank: 5}, {rank: 6}},
+	} {
+		if _, _, ok := table.Score(nil, hand); ok {
+			t.Errorf("%v: expected the hand not to be in the table.", hand)
+		}
+	}
+	two := DefaultRules()
+	two.Decks = 2
+	if scoreTableOf(&two) != nil {
+		t.Errorf("expected no table for two decks.")
+	}
+}
+
+func TestScoreTableIsSharedByRulesScoringAlike(t *testing.T) {
+	rules := DefaultRules()
+	played := DefaultRules()
+	played.Ties, played.MaxBombs, played.Step, played.End = SplitTies, 3, 2, 10
+	if scoreTableOf(&rules) != scoreTableOf(&played) {
+		t.Errorf("expected rules only differing in how the game is played to share a score table.")
+	}
+	scored := DefaultRules()
+	scored.ThreeKindBonus = 50
+	if scoreTableOf(&rules) == scoreTableOf(&scored) {
+		t.Errorf("expected rules scoring differently to have their own score table.")
+	}
+}
+
+func BenchmarkScoreTable(b *testing.B) {
+	rules := DefaultRules()
+	table := scoreTableOf(&rules)
+	hidden, public := []Card{{rank: 5, suit: "♠"}, wildCard}, []Card{{rank: 5, suit: "♦"}, jokerB, {rank: 12, suit: "♣"}, {rank: 5, suit: "♣"}}
+	b.Run("table", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			table.Score(hidden, public)
+		}
+	})
+	b.Run("evaluate", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rules.Evaluate(append(append([]Card(nil), hidden...), public...))
+		}
+	})
+}
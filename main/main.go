@@ -2,81 +2,121 @@ package main
 
 import (
 	"douji"
+	"errors"
 	"fmt"
+	"math/rand"
+	"os"
+	"time"
 )
 
 // a self-playing middle game
-type selfMiddleGame struct{}
+type selfMiddleGame struct {
+	hints *rand.Rand // when set, every decision shows the player's estimated chance of winning.
+}
+
+func (smg selfMiddleGame) Name() string { return "human" }
 
-func (smg selfMiddleGame) InOrOut(player *douji.Player, chips int) bool {
-	fmt.Printf("Asking:%s. Press y for in, anything else for out.\n", player.Name)
+// printView shows a player what they may see before deciding.
+func (smg selfMiddleGame) printView(v douji.TableView) {
+	fmt.Printf("Pot:%d, Round:%d/%d. Your hidden cards:%v, public cards:%v\n", v.Pot(), v.Round(), v.MaxRound(), v.HiddenCards(), v.Self().PublicCards())
+	fmt.Printf("Your score: %v\n", v.Breakdown())
+	for _, op := range v.Opponents() {
+		fmt.Printf("  %s(%d): %v\n", op.Name, op.Points, op.PublicCards())
+	}
+	if smg.hints != nil {
+		if e, err := douji.ViewEquity(v, 2000, smg.hints); err == nil {
+			fmt.Printf("Hint: win %.0f%%, bombed pot %.0f%%, lose %.0f%%\n", e.Win*100, e.Bomb*100, e.Lose*100)
+		}
+	}
+}
+
+func (smg selfMiddleGame) InOrOut(v douji.TableView, chips int) bool {
+	smg.printView(v)
+	fmt.Printf("Asking:%s to put in %d. Press y for in, anything else for out.\n", v.Self().Name, chips)
 	var answer string
 	fmt.Scan(&answer)
 	return answer == "y"
 }
 
-func (smg selfMiddleGame) CallOnce(p *douji.Player, step, end int, lastCall bool) int {
+func (smg selfMiddleGame) CallOnce(v douji.TableView, step, end int, lastCall bool) int {
+	p := v.Self()
+	smg.printView(v)
 	fmt.Printf("%s, how much do you want to you call:", p.Name)
-	for i := 0; i <= end; i += step {
-		fmt.Print(i, " ")
-	}
-	if lastCall {
-		fmt.Print(end*2, " ")
+	valid := map[int]bool{}
+	for _, option := range v.CallOptions() {
+		fmt.Print(option, " ")
+		valid[option] = true
 	}
 	fmt.Print("?")
 	var calling int
 	var err error
 	_, err = fmt.Scan(&calling)
-	for err != nil || calling < 0 || (lastCall && calling > 2*end) || (!lastCall && calling > end) {
-		return smg.CallOnce(p, step, end, lastCall)
+	for err != nil || !valid[calling] {
+		return smg.CallOnce(v, step, end, lastCall)
 	}
 	fmt.Printf("%s called:%d\n", p.Name, calling)
 	return calling
 }
 
-// for testing!
-type inMemoryDb struct{}
-
-func (imdb inMemoryDb) SaveGameStats(setId string, gameId int, pnp []douji.PlayerDTO) error {
-	return nil
-}
-
-func (imdb inMemoryDb) LoadPlayerStatsByName(name string) *douji.Player {
-	return douji.NewTestPlayer(name, name, 1000)
-}
-
-// SaveSet(s *Set) error
-func (imdb inMemoryDb) CreatePlayer(name, password string, points int) (string, error) {
-	return "", nil
+// loadOrCreatePlayer loads a player, creating them with 1000 points the first time they play, in the journal while LeanCloud is unreachable.
+func loadOrCreatePlayer(db douji.Db, name string) *douji.Player {
+	p, err := db.LoadPlayerStatsByName(name)
+	if errors.Is(err, douji.ErrPlayerNotFound) {
+		if _, err = db.CreatePlayer(name, "", 1000); err == nil {
+			p, err = db.LoadPlayerStatsByName(name)
+		}
+	}
+	if err != nil {
+		fmt.Printf("failed to load %s: %v\n", name, err)
+		os.Exit(1)
+	}
+	return p
 }
 
 func chooseDb() int {
-	fmt.Println("Choose database mode:\n1 in-memory (for local testing, ok to ignore missing douji.env file.)\n2 for LeanCloud.")
+	fmt.Println("Choose database mode:\n1 in-memory (for local testing, ok to ignore missing douji.env file.)\n2 for LeanCloud.\n3 for csv files in the current directory.")
 	var mode int
 	fmt.Scan(&mode)
-	if mode == 1 || mode == 2 {
+	if mode >= 1 && mode <= 3 {
 		return mode
 	}
 	fmt.Println("invalid db mode choice!")
 	return chooseDb()
 }
 
+func chooseHints() bool {
+	fmt.Println("Show hints of your chance of winning? Press y for hints, anything else for none.")
+	var answer string
+	fmt.Scan(&answer)
+	return answer == "y"
+}
+
+func chooseHumans(players int) int {
+	fmt.Printf("How many of the %d players are humans? The rest are played by bots.\n", players)
+	var humans int
+	fmt.Scan(&humans)
+	if humans >= 0 && humans <= players {
+		return humans
+	}
+	fmt.Println("invalid number of humans!")
+	return chooseHumans(players)
+}
+
 func main() {
 	var db douji.Db
 	dbMode := chooseDb()
-	if dbMode == 1 {
-		db = inMemoryDb{}
-	} else {
-		db = douji.NewLeanCloudDB()
+	switch dbMode {
+	case 1:
+		db = douji.NewMemoryDb()
+	case 2:
+		db = douji.NewLeanCloudDB().WithJournal(douji.NewJournal(douji.DefaultJournalPath))
+	default:
+		db = douji.NewCSV()
 	}
 
-	players := []*douji.Player{
-		db.LoadPlayerStatsByName("Liu"),
-		db.LoadPlayerStatsByName("Sun"),
-		db.LoadPlayerStatsByName("Gu"),
-		db.LoadPlayerStatsByName("Wang"),
-		db.LoadPlayerStatsByName("Pan"),
-		db.LoadPlayerStatsByName("Mu"),
+	var players []*douji.Player
+	for _, name := range []string{"Liu", "Sun", "Gu", "Wang", "Pan", "Mu"} {
+		players = append(players, loadOrCreatePlayer(db, name))
 	}
 
 	// douji.NewPlayer("Zhang San", "password1", 1000, db),
@@ -88,10 +128,32 @@ func main() {
 	// douji.NewPlayer("Mu", "password5", 1000, db),
 
 	p, base := 0, 1
-	s := douji.NewSet(2, true)
+	s, err := douji.NewSet(2, true, douji.DefaultRules())
+	if err != nil {
+		fmt.Println("failed to create the set:", err)
+		os.Exit(1)
+	}
 	// if err := db.SaveSet(&s); err != nil {
 	// 	fmt.Errorf("error on saving set:%w", err)
 	// }
 	hiddenCount := 1
-	s.Run(players, selfMiddleGame{}, db, base, hiddenCount, p)
+	humans := chooseHumans(len(players))
+	var human selfMiddleGame
+	if humans > 0 && chooseHints() {
+		human.hints = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	md := douji.NewDispatcher(human)
+	bots := []douji.Strategy{
+		douji.NewRandomBot(time.Now().UnixNano()),
+		douji.ThresholdBot{Fold: 10, Raise: 20},
+		douji.PotOddsBot{Equity: douji.MonteCarloEquity(500, time.Now().UnixNano())},
+	}
+	for i, player := range players[humans:] {
+		bot := bots[i%len(bots)]
+		fmt.Printf("%s is played by the %s bot.\n", player.Name, bot.Name())
+		md.Seat(player.Id(), bot)
+	}
+	if err := s.Run(players, md, db, base, hiddenCount, p); err != nil {
+		fmt.Println("The set is over but not every game was saved:", err)
+	}
 }
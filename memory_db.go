@@ -0,0 +1,62 @@
+package douji
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// MemoryDb keeps players and their points in memory, e.g. for local games and tests. It's lost once the process exits.
+type MemoryDb struct {
+	mu      sync.Mutex
+	ids     map[string]string // player ids by name.
+	points  map[string]int    // latest points by name.
+	saved   map[string]bool   // StatsKeys of the rows saved.
+	created int
+}
+
+// NewMemoryDb returns an empty MemoryDb.
+func NewMemoryDb() *MemoryDb {
+	return &MemoryDb{ids: map[string]string{}, points: map[string]int{}, saved: map[string]bool{}}
+}
+
+// SaveGameStats keeps every player's points after the game, only the latest ones are kept.
+func (db *MemoryDb) SaveGameStats(setId string, gameId int, seed int64, pot PotStats, pnp []PlayerDTO) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, p := range pnp {
+		key := StatsKey(setId, gameId, p.Id)
+		if db.saved[key] {
+			continue
+		}
+		db.saved[key] = true
+		if _, ok := db.ids[p.Name]; !ok {
+			db.ids[p.Name] = p.Id
+		}
+		db.points[p.Name] = p.Points
+	}
+	return nil
+}
+
+func (db *MemoryDb) LoadPlayerStatsByName(name string) (*Player, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	id, ok := db.ids[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPlayerNotFound, name)
+	}
+	return &Player{Name: name, id: id, points: db.points[name]}, nil
+}
+
+// CreatePlayer adds a player with a new id, the password isn't kept.
+func (db *MemoryDb) CreatePlayer(name, password string, points int) (string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.ids[name]; ok {
+		return "", fmt.Errorf("player %s already exists", name)
+	}
+	db.created++
+	id := strconv.Itoa(db.created)
+	db.ids[name], db.points[name] = id, points
+	return id, nil
+}
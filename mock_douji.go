@@ -71,29 +71,29 @@ func (m *MockMiddleGame) EXPECT() *MockMiddleGameMockRecorder {
 }
 
 // CallOnce mocks base method.
-func (m *MockMiddleGame) CallOnce(player *Player, step, end int, lastCall bool) int {
+func (m *MockMiddleGame) CallOnce(view TableView, step, end int, lastCall bool) int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CallOnce", player, step, end, lastCall)
+	ret := m.ctrl.Call(m, "CallOnce", view, step, end, lastCall)
 	ret0, _ := ret[0].(int)
 	return ret0
 }
 
 // CallOnce indicates an expected call of CallOnce.
-func (mr *MockMiddleGameMockRecorder) CallOnce(player, step, end, lastCall interface{}) *gomock.Call {
+func (mr *MockMiddleGameMockRecorder) CallOnce(view, step, end, lastCall interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CallOnce", reflect.TypeOf((*MockMiddleGame)(nil).CallOnce), player, step, end, lastCall)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CallOnce", reflect.TypeOf((*MockMiddleGame)(nil).CallOnce), view, step, end, lastCall)
 }
 
 // InOrOut mocks base method.
-func (m *MockMiddleGame) InOrOut(player *Player, callingChip int) bool {
+func (m *MockMiddleGame) InOrOut(view TableView, callingChip int) bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InOrOut", player, callingChip)
+	ret := m.ctrl.Call(m, "InOrOut", view, callingChip)
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
 // InOrOut indicates an expected call of InOrOut.
-func (mr *MockMiddleGameMockRecorder) InOrOut(player, callingChip interface{}) *gomock.Call {
+func (mr *MockMiddleGameMockRecorder) InOrOut(view, callingChip interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InOrOut", reflect.TypeOf((*MockMiddleGame)(nil).InOrOut), player, callingChip)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InOrOut", reflect.TypeOf((*MockMiddleGame)(nil).InOrOut), view, callingChip)
 }
@@ -9,11 +9,17 @@ type Card struct {
 
 type Set struct {
 	leancloud.Object
-	id         string `json:"id"`
-	gameNumber int    `json:"gameNumber"` // number of games
+	id         string
+	gameNumber int // number of games
 	// step        int
 	// end         int
 	printStatus bool
+	seed        int64
+	events      EventSink
+	timeouts    DecisionTimeouts
+	rules       RuleSet
+	joins       *joinQueue // players joining the set while it's running.
+	writer      StatsWriterConfig
 }
 
 type gameStatus int
@@ -26,16 +32,44 @@ const (
 )
 
 type Game struct {
-	id          int
-	players     []*Player
-	base        int
-	hiddenCount int
-	pot         int // chips from all players.
-	step        int
-	end         int
-	maxRound    int
-	status      gameStatus // maybe don't need this?
-	prevWinner  *Player
+	id            int
+	seated        []*Player // every player who started the game.
+	players       []*Player // players still in the game.
+	base          int
+	hiddenCount   int
+	pot           int            // chips from all players.
+	carried       int            // the bombed pot carried into the game.
+	contributions map[string]int // points each player put into the pot this game, by player id.
+	allIn         map[string]bool
+	tieBreakers   []*Player      // the players who tied on a pot carried over to a tie-break game.
+	bombs         int            // pots in a row carried over into the game.
+	carriedShares map[string]int // points each player put into the carried pot, by player id.
+	bombedShares  map[string]int // points each player put into the pots carried over to the next game.
+	bombedPlayers []*Player      // the players in the final comparison of a pot carried over.
+	forcedSplit   bool
+	buyIn         int      // points newcomers put in to play for the carried pot.
+	newcomers     []string // ids of players who didn't put points into the carried pot.
+	// the state of a started game between decisions.
+	dealer       CardDealer
+	print        bool
+	turn         TurnKind  // the decision the game waits for.
+	caller       *Player   // the calling player of the round.
+	callingPoint int       // points of the round's call.
+	asking       []*Player // players yet to decide in or out on the call.
+	inPlayers    []*Player // players in on the call so far.
+	winner       *Player
+	endPot       int
+	step         int
+	end          int
+	maxRound     int
+	round        int
+	calls        []CallRecord
+	status       gameStatus // maybe don't need this?
+	prevWinner   *Player
+	seed         int64 // seed of the shuffled deck, 0 if unknown.
+	events       EventSink
+	timeouts     DecisionTimeouts
+	rules        RuleSet
 }
 
 type Deck struct {
@@ -49,27 +83,27 @@ type CardDealer interface {
 type Hand struct {
 	privateCards []Card
 	publicCards  []Card
-	isFourKind   bool
-	hasWildCard  bool
-	hasJokerR    bool
-	hasJokerB    bool
+	scores       scoreMemo
 }
 
 type Player struct {
 	id     string
 	Name   string
 	points int
+	rules  *RuleSet // the rules of the player's current game, nil for the default rules.
 	Hand
 }
 
 // These are the operations that need to wait for players input.
+// Each decision gets a TableView showing exactly what the deciding player may see.
 type MiddleGame interface {
 	// whether stay in the game or quit when another player calls a certain amount.
-	InOrOut(player *Player, callingChip int) bool
+	InOrOut(view TableView, callingChip int) bool
 
 	// CallOnce lets a calling player either call a certain amount or choose to quit the game by calling 0.
 	// Each round calling points are [0, step, 2step...end]. In the final round, it can choose from [0, step, 2step...end, end*2]
 	// If last game is bombed, then the calling points is doubled i.e. [0, 2step, 4step, 6step ... 2*end] and in the final round the limit is 4*end.
+	// Those are the default rules, view.CallOptions() returns the calls allowed by the game's RuleSet.
 	// 0 is always an option since it indicates quitting the game.
-	CallOnce(player *Player, step, end int, lastCall bool) int
+	CallOnce(view TableView, step, end int, lastCall bool) int
 }
@@ -14,12 +14,13 @@ func isCard(card Card, targetCard Card) bool {
 	return card.rank == targetCard.rank && card.suit == targetCard.suit
 }
 
-func NewPlayer(name, password string, points int, db Db) *Player {
+// NewPlayer creates a player in db, returning the Db's error when it fails.
+func NewPlayer(name, password string, points int, db Db) (*Player, error) {
 	id, err := db.CreatePlayer(name, password, points)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("creating player %s: %w", name, err)
 	}
-	return &Player{Name: name, points: points, id: id}
+	return &Player{Name: name, points: points, id: id}, nil
 }
 
 // This shall be in the test file but because main package can't access functions in test files; it's moved here.
@@ -32,6 +33,26 @@ func NewTestPlayer(name, id string, points int) *Player {
 // 	return NewPlayer(pdto.Name)
 // }
 
+// Id returns the player's id.
+func (p *Player) Id() string {
+	return p.id
+}
+
+// Points returns the player's current points.
+func (p *Player) Points() int {
+	return p.points
+}
+
+// PrivateCards returns a copy of the player's hidden cards.
+func (p *Player) PrivateCards() []Card {
+	return append([]Card(nil), p.privateCards...)
+}
+
+// PublicCards returns a copy of the player's public cards.
+func (p *Player) PublicCards() []Card {
+	return append([]Card(nil), p.publicCards...)
+}
+
 func (p Player) String() string {
 	return fmt.Sprintf("%s(%d-%d)-**: - %v", p.Name, p.points, p.PublicScore(), p.publicCards)
 }
@@ -39,22 +60,13 @@ func (p Player) String() string {
 // ReceivePublicCard receives a new public card for the player.
 func (p *Player) ReceivePublicCard(c Card) {
 	p.publicCards = append(p.publicCards, c)
-}
-
-// returns the number of N kind according to a rank frequency map.
-func hasNKind(freqMap map[int]int, n int) int {
-	counter := 0
-	for _, v := range freqMap {
-		if v == n {
-			counter++
-		}
-	}
-	return counter
+	p.scores = scoreMemo{}
 }
 
 // ReceivePrivateCard receives a hidden card for the player.
 func (p *Player) ReceivePrivateCard(c Card) {
 	p.privateCards = append(p.privateCards, c)
+	p.scores = scoreMemo{}
 }
 
 // FaceScore returns the last public card score for a player.
@@ -68,99 +80,85 @@ func (p *Player) FaceScore() int {
 
 // PublicScore returns the total score of a player's public cards
 func (p *Player) PublicScore() int {
-	return p.calculateScore(p.publicCards)
-}
-
-// Checks whether there is any additional points from jokers including wild card. Two jokers gets additional 30 points.
-// It returns extra points and whether wild card has been used to get the extra point, if any.
-func (p *Player) checkJokerExtra() (int, bool) {
-	switch {
-	case p.hasJokerR && p.hasJokerB && p.hasWildCard:
-		return 47, true // it's like having two red Joker and one black Joker i.e. treating the wild card as red Joker (from rank 2 to rank 19).
-	case p.hasJokerR && p.hasJokerB:
-		return 30, false
-	case p.hasJokerR && p.hasWildCard:
-		return 45, true
-	case p.hasJokerB && p.hasWildCard:
-		return 47, true
-	default:
-		return 0, false
-	}
+	return p.publicBreakdown().Total
 }
 
-// getRankSumAndFreq gets the rank sum and rank frequency; it also checks whether it contains jokers and wild card, if so setting the corresponding flag.
-func (p *Player) getRankSumAndFreq(cards []Card) (map[int]int, int) {
-	freqMap := make(map[int]int, len(cards))
-	sum := 0
-	for _, c := range cards {
-		if !p.hasWildCard {
-			p.hasWildCard = isCard(c, wildCard)
-		}
-		if !p.hasJokerR {
-			p.hasJokerR = isCard(c, jokerR)
-		}
-		if !p.hasJokerB {
-			p.hasJokerB = isCard(c, jokerB)
-		}
-		freqMap[c.rank]++
-		sum += c.rank
-	}
-	return freqMap, sum
-}
-
-func (p *Player) calculateScore(cards []Card) int {
-	freqMap, score := p.getRankSumAndFreq(cards)
-	if p.hasWildCard {
-		for k, freq := range freqMap {
-			if freq == 4 && k != 2 {
-				p.isFourKind = true
-				return 300 // five a kind rules everything else!!!!
-			}
-			if freq == 3 && k != 2 {
-				p.isFourKind = true
-				freqMap[k]++
-				score += k - 2
-				p.hasWildCard = false // wild card has been used to get a four a kind!
-				freqMap[2]--          // reduce rank 2 frequency since wild card has been used.
-				break
-			}
-		}
-	}
-	extra, used := p.checkJokerExtra()
-	score += extra
-	if used {
-		p.hasWildCard = false // wild card has been used to get double jokers!
+// FinalScore returns the final score of both public and private cards for a player in a game.
+// Final score is only relevant in the final round where there are still more than one player.
+func (p *Player) FinalScore() int {
+	score, _ := p.finalScore()
+	return score
+}
+
+// IsFourKind reports whether the player's hand, including the hidden cards, has four or more a kind.
+func (p *Player) IsFourKind() bool {
+	_, fourKind := p.finalScore()
+	return fourKind
+}
+
+// PublicBreakdown explains the player's public score.
+func (p *Player) PublicBreakdown() ScoreBreakdown {
+	return p.publicBreakdown().clone()
+}
+
+// FinalBreakdown explains the player's final score.
+func (p *Player) FinalBreakdown() ScoreBreakdown {
+	return p.finalBreakdown().clone()
+}
+
+// scoreMemo caches the breakdowns of a hand's current cards, so scoring is only done once however often it's asked for.
+type scoreMemo struct {
+	rules  RuleSet // a copy, so rules changed in place are told apart too.
+	public *ScoreBreakdown
+	final  *ScoreBreakdown
+	// the final score and four a kind looked up in the score table, valid when scored is set.
+	scored   bool
+	total    int
+	fourKind bool
+}
+
+// memo returns the scores of the player's current cards, dropping what was cached under other rules.
+func (p *Player) memo() *scoreMemo {
+	if rules := *p.ruleSet(); p.scores.rules != rules {
+		p.scores = scoreMemo{rules: rules}
 	}
+	return &p.scores
+}
 
-	// check for four a kind.
-	if hasNKind(freqMap, 4) > 0 {
-		p.isFourKind = true
-		score += 60  // four a kind gets extra 60 points.
-		return score // when there is a four a kind, no need to check for three a kind.
+func (p *Player) publicBreakdown() *ScoreBreakdown {
+	m := p.memo()
+	if m.public == nil {
+		b := p.ruleSet().Evaluate(p.publicCards)
+		m.public = &b
 	}
-	if p.hasWildCard {
-		freqTwoRank := 0
-		for k, freq := range freqMap {
-			if freq == 2 && k != 2 && k > freqTwoRank { // don't treat it as three a kind if it's just a pair of 2s.
-				freqTwoRank = k // take the larger freq two rank
-			}
+	return m.public
+}
+
+// finalScore looks the final score up in the rules' score table, only evaluating hands not in the table.
+func (p *Player) finalScore() (int, bool) {
+	m := p.memo()
+	if !m.scored {
+		ok := false
+		if t := scoreTableOf(p.ruleSet()); t != nil {
+			m.total, m.fourKind, ok = t.Score(p.privateCards, p.publicCards)
 		}
-		if freqTwoRank > 0 {
-			freqMap[freqTwoRank]++
-			score += freqTwoRank - 2
+		if !ok {
+			b := p.finalBreakdown()
+			m.total, m.fourKind = b.Total, b.FourKind
 		}
+		m.scored = true
 	}
-
-	// check for three a kind. Possible to have more than one 3 a kind in a hand of 6 cards.
-	score += 30 * hasNKind(freqMap, 3)
-	return score
+	return m.total, m.fourKind
 }
 
-// FinalScore returns the final score of both public and private cards for a player in a game.
-// Final score is only relevant in the final round where there are still more than one player.
-func (p *Player) FinalScore() int {
-	all := append(p.privateCards, p.publicCards...)
-	return p.calculateScore(all)
+func (p *Player) finalBreakdown() *ScoreBreakdown {
+	m := p.memo()
+	if m.final == nil {
+		all := make([]Card, 0, len(p.privateCards)+len(p.publicCards))
+		b := p.ruleSet().Evaluate(append(append(all, p.privateCards...), p.publicCards...))
+		m.final = &b
+	}
+	return m.final
 }
 
 func (p *Player) ClearHand() {
@@ -0,0 +1,204 @@
+package douji
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ReplayResult is the final state of a replayed game.
+type ReplayResult struct {
+	Players []*Player // every player who started the game, holding their final points.
+	Winner  *Player   // nil when the pot was bombed.
+	Pot     int       // the bombed pot carried to the next game, 0 when there's a winner.
+	Events  []Event   // events published while replaying.
+}
+
+// scriptedDealer deals recorded cards in their original order.
+type scriptedDealer struct {
+	cards []Card
+	err   error
+}
+
+func (d *scriptedDealer) DealOne() Card {
+	if len(d.cards) == 0 {
+		if d.err == nil {
+			d.err = errors.New("replay dealt more cards than recorded")
+		}
+		return Card{}
+	}
+	c := d.cards[0]
+	d.cards = d.cards[1:]
+	return c
+}
+
+// scriptedMiddleGame answers CallOnce and InOrOut with recorded decisions, checking they are asked of the same players.
+// Decisions recorded after a DecisionTimedOut time out again and those after a DecisionFailed fail again.
+type scriptedMiddleGame struct {
+	calls        []Called
+	callErrs     []error
+	decisions    []InOrOutDecided
+	decisionErrs []error
+	err          error
+}
+
+func (s *scriptedMiddleGame) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *scriptedMiddleGame) CallOnce(view TableView, step, end int, lastCall bool) int {
+	player := view.Self()
+	if len(s.calls) == 0 {
+		s.fail(fmt.Errorf("replay asked %s to call but no call was recorded", player.Id))
+		return 0
+	}
+	c := s.calls[0]
+	s.calls, s.callErrs = s.calls[1:], s.callErrs[1:]
+	if c.PlayerId != player.Id {
+		s.fail(fmt.Errorf("round %d: replay asked %s to call but %s called", c.Round, player.Id, c.PlayerId))
+	}
+	return c.Points
+}
+
+func (s *scriptedMiddleGame) InOrOut(view TableView, callingChip int) bool {
+	player := view.Self()
+	if len(s.decisions) == 0 {
+		s.fail(fmt.Errorf("replay asked %s for in or out but no decision was recorded", player.Id))
+		return false
+	}
+	d := s.decisions[0]
+	s.decisions, s.decisionErrs = s.decisions[1:], s.decisionErrs[1:]
+	if d.PlayerId != player.Id || d.Points != callingChip {
+		s.fail(fmt.Errorf("round %d: replay asked %s for in or out on %d but %s decided on %d", d.Round, player.Id, callingChip, d.PlayerId, d.Points))
+	}
+	return d.In
+}
+
+func (s *scriptedMiddleGame) CallOnceContext(ctx context.Context, view TableView, step, end int, lastCall bool) (int, error) {
+	var err error
+	if len(s.callErrs) > 0 {
+		err = s.callErrs[0]
+	}
+	points := s.CallOnce(view, step, end, lastCall)
+	if err != nil {
+		return 0, err
+	}
+	return points, nil
+}
+
+func (s *scriptedMiddleGame) InOrOutContext(ctx context.Context, view TableView, callingChip int) (bool, error) {
+	var err error
+	if len(s.decisionErrs) > 0 {
+		err = s.decisionErrs[0]
+	}
+	in := s.InOrOut(view, callingChip)
+	if err != nil {
+		return false, err
+	}
+	return in, nil
+}
+
+// SplitGames splits the events recorded from a set into one slice per game, each starting with GameStarted.
+func SplitGames(events []Event) [][]Event {
+	var games [][]Event
+	for _, e := range events {
+		if _, ok := e.(GameStarted); ok {
+			games = append(games, nil)
+		}
+		if len(games) > 0 {
+			games[len(games)-1] = append(games[len(games)-1], e)
+		}
+	}
+	return games
+}
+
+// Replay rebuilds a game from its recorded events and re-runs it with the recorded cards and decisions.
+// events must start with GameStarted; anything from the next GameStarted onwards is ignored.
+// It returns an error if the replayed game asks different players or publishes different events than recorded.
+func Replay(events []Event) (*ReplayResult, error) {
+	if len(events) == 0 {
+		return nil, errors.New("no events to replay")
+	}
+	started, ok := events[0].(GameStarted)
+	if !ok {
+		return nil, fmt.Errorf("expected the first event to be GameStarted but got:%s", events[0].Kind())
+	}
+	recorded := SplitGames(events)[0]
+
+	dealer := &scriptedDealer{}
+	md := &scriptedMiddleGame{}
+	decision, failed, rejected := "", error(nil), 0
+	failedOn := func(d string) error { // the error a recorded decision of d failed with, if any.
+		if decision == d {
+			return failed
+		}
+		return nil
+	}
+	for _, e := range recorded {
+		switch ev := e.(type) {
+		case CardDealt:
+			dealer.cards = append(dealer.cards, ev.Card)
+		case DecisionTimedOut:
+			decision, failed = ev.Decision, context.DeadlineExceeded
+		case DecisionFailed:
+			decision, failed = ev.Decision, errors.New(ev.Reason)
+		case CallRejected:
+			md.calls = append(md.calls, Called{GameId: ev.GameId, Round: ev.Round, PlayerId: ev.PlayerId, Points: ev.Points})
+			md.callErrs = append(md.callErrs, nil)
+			rejected++
+		case Called:
+			if rejected < maxCallAttempts { // the game quits for the player after too many rejected calls without asking.
+				md.calls = append(md.calls, ev)
+				md.callErrs = append(md.callErrs, failedOn(callDecision))
+			}
+			decision, failed, rejected = "", nil, 0
+		case InOrOutDecided:
+			md.decisions = append(md.decisions, ev)
+			md.decisionErrs = append(md.decisionErrs, failedOn(inOrOutDecision))
+			decision, failed = "", nil
+		}
+	}
+
+	players := make([]*Player, len(started.Players))
+	var prevWinner *Player
+	for i, pdto := range started.Players {
+		players[i] = &Player{id: pdto.Id, Name: pdto.Name, points: pdto.Points}
+		if started.PrevWinnerId != "" && pdto.Id == started.PrevWinnerId {
+			prevWinner = players[i]
+		}
+	}
+	game := NewGame(started.GameId, append([]*Player(nil), players...), started.Base, started.HiddenCount, started.Pot, started.Step, started.End, prevWinner, started.Rules)
+	game.seed = started.Seed
+	game.bombs, game.buyIn, game.newcomers = started.Bombs, started.BuyIn, started.Newcomers
+	game.SetDecisionTimeouts(DecisionTimeouts{Call: time.Hour, InOrOut: time.Hour}) // recorded timeouts are replayed by the script, not by the clock.
+	log := &EventLog{}
+	game.SetEventSink(log)
+	winner, pot, err := game.run(false, md, dealer)
+
+	result := &ReplayResult{Players: players, Winner: winner, Pot: pot, Events: log.Events()}
+	switch {
+	case err != nil:
+		return result, err
+	case md.err != nil:
+		return result, md.err
+	case dealer.err != nil:
+		return result, dealer.err
+	case len(md.calls) > 0 || len(md.decisions) > 0:
+		return result, fmt.Errorf("replay finished with %d calls and %d decisions unused", len(md.calls), len(md.decisions))
+	case len(dealer.cards) > 0:
+		return result, fmt.Errorf("replay finished with %d cards undealt", len(dealer.cards))
+	}
+	for i := range recorded {
+		if i >= len(result.Events) || !reflect.DeepEqual(recorded[i], result.Events[i]) {
+			return result, fmt.Errorf("replay diverged at event %d (%s)", i, recorded[i].Kind())
+		}
+	}
+	if len(result.Events) != len(recorded) {
+		return result, fmt.Errorf("replay published %d events but %d were recorded", len(result.Events), len(recorded))
+	}
+	return result, nil
+}
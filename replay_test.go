@@ -0,0 +1,82 @@
+package douji
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func recordStubbedGame(t *testing.T) ([]*Player, []Event) {
+	players := getFourTestingPlayers()
+	cardDealer, mg := getStubs(players, gomock.NewController(t))
+	game := NewGame(0, players, 1, 1, 0, 1, 5, nil, DefaultRules())
+	log := &EventLog{}
+	game.SetEventSink(log)
+	game.run(false, mg, cardDealer)
+	return players, log.Events()
+}
+
+func TestReplay(t *testing.T) {
+	players, events := recordStubbedGame(t)
+	result, err := Replay(events)
+	if err != nil {
+		t.Fatalf("expected replay to reproduce the game but got:%v", err)
+	}
+	if result.Winner == nil || result.Winner.Name != "Liu" {
+		t.Fatalf("expected Liu to win the replayed game but got:%v", result.Winner)
+	}
+	if result.Pot != 0 {
+		t.Errorf("expected a zero pot at the end of replayed game but got:%d", result.Pot)
+	}
+	for i, p := range result.Players {
+		if p.points != players[i].points {
+			t.Errorf("expected %s to have %d points after replay but got:%d", p.Name, players[i].points, p.points)
+		}
+	}
+}
+
+func TestReplayDetectsTamperedLog(t *testing.T) {
+	_, events := recordStubbedGame(t)
+	for i, e := range events {
+		if c, ok := e.(Called); ok {
+			c.PlayerId = "2" // claim Gu made the first call instead of Liu.
+			events[i] = c
+			break
+		}
+	}
+	if _, err := Replay(events); err == nil {
+		t.Errorf("expected replay to reject a log with a call from the wrong player.")
+	}
+}
+
+func TestReplayRequiresGameStarted(t *testing.T) {
+	if _, err := Replay(nil); err == nil {
+		t.Errorf("expected an error replaying no events.")
+	}
+	if _, err := Replay([]Event{RoundEnded{}}); err == nil {
+		t.Errorf("expected an error replaying events not starting with GameStarted.")
+	}
+}
+
+func TestSplitGames(t *testing.T) {
+	events := []Event{RoundEnded{}, GameStarted{GameId: 0}, Called{}, GameStarted{GameId: 1}, Called{}, WinnerPaid{}}
+	games := SplitGames(events)
+	if len(games) != 2 {
+		t.Fatalf("expected 2 games but got:%d", len(games))
+	}
+	if len(games[0]) != 2 || len(games[1]) != 3 {
+		t.Errorf("expected games of 2 and 3 events but got:%d and %d", len(games[0]), len(games[1]))
+	}
+}
+
+func TestReplayWithoutPrevWinner(t *testing.T) {
+	players := getFourTestingPlayers()
+	players[1].id = "" // a player without an id isn't the previous winner of the first game.
+	game := NewGame(0, players, 1, 2, 0, 1, 5, nil, DefaultRules())
+	log := &EventLog{}
+	game.SetEventSink(log)
+	game.run(false, alwaysInMiddleGame{}, NewSeededDeck(2))
+	if _, err := Replay(log.Events()); err != nil {
+		t.Errorf("expected a game without a previous winner to replay but got:%v", err)
+	}
+}
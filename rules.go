@@ -0,0 +1,179 @@
+package douji
+
+import "fmt"
+
+// RuleSet holds the rules which differ between tables: how hands score, how many rounds a game has and what can be called.
+type RuleSet struct {
+	// WildCard becomes whichever card scores the most in a hand.
+	WildCard Card
+	// FiveKindScore is the constant final score of a five a kind, beating every other hand.
+	FiveKindScore int
+	FourKindBonus int
+	// ThreeKindBonus is added for each three a kind in a hand.
+	ThreeKindBonus int
+	// JokerPairBonus is added for two jokers. A wild card turned into the missing joker also scores as that joker.
+	JokerPairBonus int
+	// OneHiddenRounds and TwoHiddenRounds are the rounds after a game starts with one or two hidden cards.
+	OneHiddenRounds int
+	TwoHiddenRounds int
+	// Step and End make the calling ladder [0, step, 2step...end] of a game after a won game.
+	Step int
+	End  int
+	// FinalCallFactor makes the extra call of the final round, i.e. end*FinalCallFactor.
+	FinalCallFactor int
+	// BombFactor multiplies step and end after every bombed pot.
+	BombFactor int
+	// Decks is how many 55 card decks are shuffled together for each game.
+	Decks int
+	// Ties decides what happens to a pot when the top final scores tie, bombing it by default.
+	Ties TiePolicy
+	// OddChip decides who gets the points left over when a tied pot is split unevenly.
+	OddChip OddChipRule
+	// Rejoin decides which of the players who put points into a bombed pot play the next game for it.
+	Rejoin RejoinRule
+	// Newcomers decides whether players who didn't put points into a bombed pot can play for it.
+	Newcomers NewcomerRule
+	// MaxBombs is how many pots in a row can be carried over before a tie is split instead, 0 for no limit.
+	MaxBombs int
+}
+
+// RejoinRule decides who plays the game after a bombed pot.
+type RejoinRule int
+
+const (
+	// RejoinContributors lets every player who put points into the bombed pot play for it again, even one who folded.
+	RejoinContributors RejoinRule = iota
+	// RejoinShowdown only lets the players who reached the final comparison play for it again.
+	RejoinShowdown
+)
+
+// NewcomerRule decides whether a player who didn't put points into a bombed pot, e.g. one who joined the set since, plays for it.
+type NewcomerRule int
+
+const (
+	// NewcomersWait sits newcomers out until the bombed pot is won.
+	NewcomersWait NewcomerRule = iota
+	// NewcomersBuyIn lets newcomers play after putting in as many points as the most any player put into the bombed pot.
+	NewcomersBuyIn
+	// NewcomersFree lets newcomers play for the bombed pot without putting anything in.
+	NewcomersFree
+)
+
+// TiePolicy decides what happens to a pot when the top final scores tie.
+type TiePolicy int
+
+const (
+	// BombTies carries the pot over to the next game, played by everyone at raised stakes.
+	BombTies TiePolicy = iota
+	// SplitTies shares the pot equally between the tied players.
+	SplitTies
+	// TieBreakTies carries the pot over to a tie-break game only the tied players play, at the usual stakes.
+	TieBreakTies
+)
+
+// OddChipRule decides who gets the points left over when a split pot doesn't divide evenly, one point each.
+type OddChipRule int
+
+const (
+	// OddChipBySeat gives the odd chips to the tied players in seating order.
+	OddChipBySeat OddChipRule = iota
+	// OddChipByHighCard gives the odd chips to the tied players holding the highest card first, then in seating order.
+	OddChipByHighCard
+)
+
+var defaultRules = DefaultRules()
+
+// DefaultRules returns the rules the game has always been played with.
+func DefaultRules() RuleSet {
+	return RuleSet{
+		WildCard:        wildCard,
+		FiveKindScore:   300,
+		FourKindBonus:   60,
+		ThreeKindBonus:  30,
+		JokerPairBonus:  30,
+		OneHiddenRounds: 4,
+		TwoHiddenRounds: 5,
+		Step:            1,
+		End:             5,
+		FinalCallFactor: 2,
+		BombFactor:      2,
+		Decks:           1,
+	}
+}
+
+// Validate checks the rules can be played with.
+func (r RuleSet) Validate() error {
+	found := false
+	for _, c := range createCards() {
+		found = found || isCard(c, r.WildCard)
+	}
+	switch {
+	case !found:
+		return fmt.Errorf("wild card %v is not in the deck", r.WildCard)
+	case r.OneHiddenRounds < 1 || r.TwoHiddenRounds < 1:
+		return fmt.Errorf("a game needs at least one round but got %d and %d", r.OneHiddenRounds, r.TwoHiddenRounds)
+	case r.Step < 1 || r.End < r.Step:
+		return fmt.Errorf("invalid calling ladder with step:%d and end:%d", r.Step, r.End)
+	case r.FinalCallFactor < 1 || r.BombFactor < 1:
+		return fmt.Errorf("factors must be at least 1 but got final call factor:%d and bomb factor:%d", r.FinalCallFactor, r.BombFactor)
+	case r.Decks < 1:
+		return fmt.Errorf("a game needs at least one deck but got:%d", r.Decks)
+	case r.Ties < BombTies || r.Ties > TieBreakTies:
+		return fmt.Errorf("unknown tie policy:%d", r.Ties)
+	case r.OddChip < OddChipBySeat || r.OddChip > OddChipByHighCard:
+		return fmt.Errorf("unknown odd chip rule:%d", r.OddChip)
+	case r.Rejoin < RejoinContributors || r.Rejoin > RejoinShowdown:
+		return fmt.Errorf("unknown rejoin rule:%d", r.Rejoin)
+	case r.Newcomers < NewcomersWait || r.Newcomers > NewcomersFree:
+		return fmt.Errorf("unknown newcomer rule:%d", r.Newcomers)
+	case r.MaxBombs < 0:
+		return fmt.Errorf("max bombs can't be negative but got:%d", r.MaxBombs)
+	}
+	return nil
+}
+
+// orDefault returns the default rules for the zero RuleSet.
+func (r RuleSet) orDefault() RuleSet {
+	if r == (RuleSet{}) {
+		return defaultRules
+	}
+	return r
+}
+
+// rounds returns the rounds of a game after it starts.
+func (r RuleSet) rounds(hiddenCount int) int {
+	if hiddenCount > 1 {
+		return r.TwoHiddenRounds // with two hidden cards, there are an extra round.
+	}
+	return r.OneHiddenRounds
+}
+
+// cardsPerPlayer returns the most cards a player is dealt in a game: two when it starts and one in every round but the final round.
+func (r RuleSet) cardsPerPlayer(hiddenCount int) int {
+	return 2 + r.rounds(hiddenCount) - 1
+}
+
+// MaxPlayers returns how many players the decks can serve in a game with hiddenCount hidden cards.
+func (r RuleSet) MaxPlayers(hiddenCount int) int {
+	return r.Decks * deckSize / r.cardsPerPlayer(hiddenCount)
+}
+
+// callLadder returns the calls a calling player can choose from: [0, step, 2step...end] plus end*FinalCallFactor in the final round.
+func (r RuleSet) callLadder(step, end int, lastCall bool) []int {
+	ladder := []int{0}
+	for c := step; step > 0 && c <= end; c += step {
+		ladder = append(ladder, c)
+	}
+	if lastCall && r.FinalCallFactor > 1 {
+		ladder = append(ladder, end*r.FinalCallFactor)
+	}
+	return ladder
+}
+
+// ruleSet returns the rules the player's hand is scored by; a player who never played a game is scored by the default rules.
+func (p *Player) ruleSet() *RuleSet {
+	if p.rules == nil {
+		return &defaultRules
+	}
+	return p.rules
+}
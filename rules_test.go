@@ -0,0 +1,118 @@
+package douji
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCallLadder(t *testing.T) {
+	tripleFinal := DefaultRules()
+	tripleFinal.FinalCallFactor = 3
+	for _, tc := range []struct {
+		rules     RuleSet
+		step, end int
+		lastCall  bool
+		want      []int
+	}{
+		{DefaultRules(), 1, 5, false, []int{0, 1, 2, 3, 4, 5}},
+		{DefaultRules(), 1, 5, true, []int{0, 1, 2, 3, 4, 5, 10}},
+		{DefaultRules(), 2, 10, true, []int{0, 2, 4, 6, 8, 10, 20}},
+		{tripleFinal, 1, 3, true, []int{0, 1, 2, 3, 9}},
+	} {
+		if got := tc.rules.callLadder(tc.step, tc.end, tc.lastCall); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("callLadder(%d, %d, %v) = %v, want %v", tc.step, tc.end, tc.lastCall, got, tc.want)
+		}
+	}
+}
+
+func TestRuleSetValidate(t *testing.T) {
+	if err := DefaultRules().Validate(); err != nil {
+		t.Errorf("expected the default rules to be valid but got:%v", err)
+	}
+	for name, change := range map[string]func(r *RuleSet){
+		"wild card not in deck": func(r *RuleSet) { r.WildCard = NewCard(14, "♠") },
+		"no rounds":             func(r *RuleSet) { r.OneHiddenRounds = 0 },
+		"end below step":        func(r *RuleSet) { r.Step, r.End = 5, 1 },
+		"zero bomb factor":      func(r *RuleSet) { r.BombFactor = 0 },
+	} {
+		r := DefaultRules()
+		change(&r)
+		if r.Validate() == nil {
+			t.Errorf("%s: expected an error.", name)
+		}
+		if _, err := NewSet(1, false, r); err == nil {
+			t.Errorf("%s: expected NewSet to return an error.", name)
+		}
+	}
+}
+
+func TestZeroRulesAreDefault(t *testing.T) {
+	s, err := NewSet(1, false, RuleSet{})
+	if err != nil || s.rules != DefaultRules() {
+		t.Errorf("expected a set of the default rules but got:%+v, %v", s.rules, err)
+	}
+	game := NewGame(0, getFourTestingPlayers(), 1, 1, 0, 1, 5, nil, RuleSet{})
+	if err := game.Start(NewSeededDeck(1)); err != nil || game.rules != DefaultRules() {
+		t.Errorf("expected a game of the default rules but got:%+v, %v", game.rules, err)
+	}
+	invalid := DefaultRules()
+	invalid.Step = 0
+	if err := NewGame(0, getFourTestingPlayers(), 1, 1, 0, 1, 5, nil, invalid).Start(NewSeededDeck(1)); err == nil {
+		t.Errorf("expected a game of invalid rules not to start.")
+	}
+}
+
+func TestCustomRulesScoring(t *testing.T) {
+	spadeTwo := DefaultRules()
+	spadeTwo.WildCard = NewCard(2, "♠")
+	spadeTwo.FourKindBonus, spadeTwo.ThreeKindBonus, spadeTwo.JokerPairBonus = 100, 50, 40
+	for _, tc := range []struct {
+		name  string
+		cards []Card
+		want  int
+	}{
+		{"hearts-2 is not wild", []Card{{rank: 5}, {rank: 5}, wildCard}, 12},
+		{"spade-2 is wild", []Card{{rank: 5}, {rank: 5}, NewCard(2, "♠")}, 15 + 50},
+		{"four a kind bonus", []Card{{rank: 5}, {rank: 5}, {rank: 5}, NewCard(2, "♠")}, 20 + 100},
+		{"joker pair bonus", []Card{jokerB, jokerR}, 36 + 40},
+		{"wild card as red joker", []Card{jokerB, NewCard(2, "♠")}, 36 + 40},
+	} {
+		if got := spadeTwo.Evaluate(tc.cards).Total; got != tc.want {
+			t.Errorf("%s: expected %d but got:%d", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestSetRunWithRules(t *testing.T) {
+	rules := DefaultRules()
+	rules.OneHiddenRounds, rules.Step, rules.End, rules.BombFactor = 2, 3, 6, 3
+	players := getFourTestingPlayers()
+	log := &EventLog{}
+	s := newTestSet(20, rules)
+	s.SetSeed(11)
+	s.SetEventSink(log)
+	s.Run(players, alwaysInMiddleGame{}, &recordingDb{}, 1, 1, 0)
+
+	step, bombed := 0, false
+	for _, game := range SplitGames(log.Events()) {
+		started := game[0].(GameStarted)
+		want := rules.Step
+		if bombed {
+			want = step * rules.BombFactor
+		}
+		if started.Step != want || started.End != want*rules.End/rules.Step || started.Rules != rules {
+			t.Errorf("game %d: expected step %d with the set's rules but got:%+v", started.GameId, want, started)
+		}
+		step, bombed = started.Step, false
+		for _, e := range game {
+			switch ev := e.(type) {
+			case RoundEnded:
+				if ev.Round > rules.OneHiddenRounds {
+					t.Errorf("game %d: expected at most %d rounds but got round:%d", started.GameId, rules.OneHiddenRounds, ev.Round)
+				}
+			case PotBombed:
+				bombed = true
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package douji
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KindBonus is the bonus of a three or four a kind in a hand.
+type KindBonus struct {
+	Rank  int
+	Count int // 3 or 4.
+	Bonus int
+}
+
+// ScoreBreakdown explains how a hand scores.
+type ScoreBreakdown struct {
+	// RankSum is the sum of every card's rank, counting each wild card as what it became.
+	RankSum int
+	// Wilds holds what each wild card became: a card of the rank it completed a kind of (with no suit), a joker,
+	// or the wild card itself when it was left unused.
+	Wilds      []Card
+	JokerBonus int
+	Kinds      []KindBonus // highest rank first.
+	// FiveKind is set when the hand has five or more a kind, which overrides everything else and scores FiveKindRank's five a kind score.
+	FiveKind     bool
+	FiveKindRank int
+	// FourKind is set for a hand with four or more a kind; a single four a kind in the final round wins the game.
+	FourKind bool
+	Total    int
+}
+
+func (b ScoreBreakdown) String() string {
+	if b.FiveKind {
+		return fmt.Sprintf("%d = five a kind of %d", b.Total, b.FiveKindRank)
+	}
+	parts := []string{fmt.Sprintf("%d ranks", b.RankSum)}
+	if b.JokerBonus > 0 {
+		parts = append(parts, fmt.Sprintf("%d two jokers", b.JokerBonus))
+	}
+	for _, k := range b.Kinds {
+		parts = append(parts, fmt.Sprintf("%d %d a kind of %d", k.Bonus, k.Count, k.Rank))
+	}
+	s := fmt.Sprintf("%d = %s", b.Total, strings.Join(parts, " + "))
+	if len(b.Wilds) > 0 {
+		s += fmt.Sprintf(" (wild cards as %v)", b.Wilds)
+	}
+	return s
+}
+
+// clone returns a copy of the breakdown not sharing any slice.
+func (b ScoreBreakdown) clone() ScoreBreakdown {
+	b.Wilds = append([]Card(nil), b.Wilds...)
+	b.Kinds = append([]KindBonus(nil), b.Kinds...)
+	return b
+}
+
+// returns the highest rank other than the wild card's having exactly n cards according to a rank frequency map, 0 if none.
+func highestNKind(freqMap map[int]int, n, wildRank int) int {
+	rank := 0
+	for k, freq := range freqMap {
+		if freq == n && k != wildRank && k > rank {
+			rank = k
+		}
+	}
+	return rank
+}
+
+// Evaluate scores cards without touching any hand. With more than one deck a hand can hold identical cards:
+// five or more a kind all score the five a kind score, every four a kind and three a kind gets its bonus
+// and two or more jokers of any colour get the joker pair bonus once.
+// Each wild card in turn completes a four a kind from a three a kind, or becomes a joker when the hand has any
+// (the missing colour for a single joker, red otherwise), or completes a three a kind from the highest pair
+// unless the wild cards left make a four a kind of their own rank. A wild card left unused scores as a card of its own rank,
+// and one that became a joker or completed a three a kind still counts as a card of its own rank for kinds.
+func (r RuleSet) Evaluate(cards []Card) ScoreBreakdown {
+	var b ScoreBreakdown
+	wild := r.WildCard.rank
+	freqMap := make(map[int]int, len(cards))
+	wilds, jokersR, jokersB := 0, 0, 0
+	for _, c := range cards {
+		switch {
+		case isCard(c, r.WildCard):
+			wilds++
+			continue // a wild card's rank depends on what it becomes.
+		case isCard(c, jokerR):
+			jokersR++
+		case isCard(c, jokerB):
+			jokersB++
+		}
+		freqMap[c.rank]++
+		b.RankSum += c.rank
+	}
+	if wilds > 0 && freqMap[wild]+wilds >= 5 {
+		return b.fiveKind(r, wild, r.WildCard, wilds) // unused wild cards are cards of their own rank.
+	}
+	five := 0
+	for k, freq := range freqMap {
+		if freq+wilds >= 5 && k > five {
+			five = k
+		}
+	}
+	if five > 0 {
+		return b.fiveKind(r, five, Card{rank: five}, wilds) // five a kind rules everything else!!!!
+	}
+
+	ghosts := 0 // wild cards that became a joker or completed a three a kind.
+	for ; wilds > 0; wilds-- {
+		var become Card
+		k := highestNKind(freqMap, 3, wild)
+		switch {
+		case k > 0:
+			become = Card{rank: k} // wild card has been used to get a four a kind!
+		case jokersR+jokersB == 1 && jokersR == 1:
+			become = jokerB // wild card has been used to get double jokers!
+			jokersB++
+		case jokersR+jokersB > 0:
+			become = jokerR // as this results the max point increase.
+			jokersR++
+		case freqMap[wild]+wilds == 4:
+			// the wild cards are kept as cards of their own rank to make a four a kind.
+		default:
+			become = Card{rank: highestNKind(freqMap, 2, wild)} // don't treat it as three a kind if it's just a pair of the wild card's rank.
+		}
+		if become.rank == 0 {
+			break // nothing to become, the remaining wild cards are unused.
+		}
+		if k == 0 {
+			ghosts++
+		}
+		b.Wilds = append(b.Wilds, become)
+		freqMap[become.rank]++
+		b.RankSum += become.rank
+	}
+	for i := 0; i < wilds; i++ {
+		b.Wilds = append(b.Wilds, r.WildCard)
+	}
+	b.RankSum += wild * wilds
+	freqMap[wild] += wilds + ghosts // as in a single deck, such a wild card still counts as its own rank for kinds.
+
+	if jokersR+jokersB >= 2 {
+		b.JokerBonus = r.JokerPairBonus
+	}
+	b.Total = b.RankSum + b.JokerBonus
+	for k, freq := range freqMap {
+		switch freq {
+		case 4:
+			b.FourKind = true
+			b.Kinds = append(b.Kinds, KindBonus{Rank: k, Count: 4, Bonus: r.FourKindBonus}) // four a kind gets extra points.
+		case 3:
+			b.Kinds = append(b.Kinds, KindBonus{Rank: k, Count: 3, Bonus: r.ThreeKindBonus}) // possible to have more than one 3 a kind in a hand of 6 cards.
+		}
+	}
+	sort.Slice(b.Kinds, func(i, j int) bool { return b.Kinds[i].Rank > b.Kinds[j].Rank })
+	for _, k := range b.Kinds {
+		b.Total += k.Bonus
+	}
+	return b
+}
+
+// fiveKind overrides the breakdown with a five a kind of rank, turning every wild card into as.
+func (b ScoreBreakdown) fiveKind(r RuleSet, rank int, as Card, wilds int) ScoreBreakdown {
+	for i := 0; i < wilds; i++ {
+		b.Wilds = append(b.Wilds, as)
+	}
+	b.RankSum += as.rank * wilds
+	b.FiveKind, b.FiveKindRank, b.FourKind = true, rank, true
+	b.Total = r.FiveKindScore
+	return b
+}
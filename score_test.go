@@ -0,0 +1,161 @@
+package douji
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+func TestEvaluate(t *testing.T) {
+	rules := DefaultRules()
+	for _, tc := range []struct {
+		name  string
+		cards []Card
+		want  ScoreBreakdown
+	}{
+		{
+			name:  "no extra",
+			cards: []Card{{rank: 10}, {rank: 13}},
+			want:  ScoreBreakdown{RankSum: 23, Total: 23},
+		},
+		{
+			name:  "wild card completes a three a kind of the higher pair",
+			cards: []Card{{rank: 4}, {rank: 4}, {rank: 5}, {rank: 5}, wildCard},
+			want:  ScoreBreakdown{RankSum: 23, Wilds: []Card{{rank: 5}}, Kinds: []KindBonus{{Rank: 5, Count: 3, Bonus: 30}}, Total: 53},
+		},
+		{
+			name:  "wild card becomes the missing joker",
+			cards: []Card{jokerB, wildCard, {rank: 3}, {rank: 3}},
+			want:  ScoreBreakdown{RankSum: 42, Wilds: []Card{jokerR}, JokerBonus: 30, Total: 72},
+		},
+		{
+			name:  "four a kind and three a kind",
+			cards: []Card{{rank: 6}, {rank: 6}, {rank: 6}, wildCard, {rank: 9}, {rank: 9}, {rank: 9}},
+			want: ScoreBreakdown{RankSum: 54, Wilds: []Card{{rank: 9}}, FourKind: true, Total: 54 + 60 + 30,
+				Kinds: []KindBonus{{Rank: 9, Count: 4, Bonus: 60}, {Rank: 6, Count: 3, Bonus: 30}}},
+		},
+		{
+			name:  "unused wild card",
+			cards: []Card{wildCard, {rank: 7}},
+			want:  ScoreBreakdown{RankSum: 9, Wilds: []Card{wildCard}, Total: 9},
+		},
+		{
+			name:  "five a kind overrides everything",
+			cards: []Card{{rank: 8}, {rank: 8}, {rank: 8}, {rank: 8}, wildCard},
+			want:  ScoreBreakdown{RankSum: 40, Wilds: []Card{{rank: 8}}, FiveKind: true, FiveKindRank: 8, FourKind: true, Total: 300},
+		},
+	} {
+		cards := append([]Card(nil), tc.cards...)
+		got := rules.Evaluate(tc.cards)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: expected %+v but got %+v", tc.name, tc.want, got)
+		}
+		if !reflect.DeepEqual(cards, tc.cards) {
+			t.Errorf("%s: expected the cards to be untouched.", tc.name)
+		}
+		p := &Player{Hand: Hand{publicCards: tc.cards}}
+		if p.PublicScore() != got.Total || p.PublicBreakdown().Total != got.Total {
+			t.Errorf("%s: expected the public score to match the breakdown total %d.", tc.name, got.Total)
+		}
+	}
+}
+
+func TestScoreBreakdownString(t *testing.T) {
+	b := DefaultRules().Evaluate([]Card{jokerB, wildCard, {rank: 3}, {rank: 3}, {rank: 3}})
+	if got, want := b.String(), "89 = 29 ranks + 60 4 a kind of 3 (wild cards as [3])"; got != want {
+		t.Errorf("expected %q but got %q", want, got)
+	}
+}
+
+// randomHand is up to 8 cards from two decks, biased towards wild cards and jokers so the interesting rules get exercised.
+type randomHand []Card
+
+func (randomHand) Generate(r *rand.Rand, size int) reflect.Value {
+	cards := createDecks(2)
+	r.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+	hand := randomHand(cards[:1+r.Intn(8)])
+	for i := range hand {
+		switch r.Intn(10) {
+		case 0:
+			hand[i] = wildCard
+		case 1:
+			hand[i] = jokerR
+		case 2:
+			hand[i] = jokerB
+		case 3:
+			hand[i] = hand[0]
+		}
+	}
+	return reflect.ValueOf(hand)
+}
+
+func TestScoreIsOrderIndependent(t *testing.T) {
+	rules := DefaultRules()
+	f := func(hand randomHand, seed int64) bool {
+		shuffled := append([]Card(nil), hand...)
+		rand.New(rand.NewSource(seed)).Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		return reflect.DeepEqual(rules.Evaluate(hand), rules.Evaluate(shuffled))
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 5000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestScoreIsIdempotent(t *testing.T) {
+	f := func(hand randomHand, hidden uint8) bool {
+		n := int(hidden) % (len(hand) + 1)
+		// spare capacity lets an append alias the hidden cards.
+		private := make([]Card, n, n+len(hand))
+		copy(private, hand[:n])
+		backing := append([]Card(nil), private[:cap(private)]...)
+		p := &Player{Hand: Hand{privateCards: private, publicCards: append([]Card(nil), hand[n:]...)}}
+		fresh := &Player{Hand: Hand{privateCards: append([]Card(nil), hand[:n]...), publicCards: append([]Card(nil), hand[n:]...)}}
+		want := fresh.FinalScore()
+
+		_ = p.String()
+		ps, fs, fk := p.PublicScore(), p.FinalScore(), p.IsFourKind()
+		for i := 0; i < 3; i++ {
+			if p.PublicScore() != ps || p.FinalScore() != fs || p.IsFourKind() != fk {
+				return false
+			}
+		}
+		return fs == want && ps == DefaultRules().Evaluate(hand[n:]).Total &&
+			reflect.DeepEqual(private[:cap(private)], backing)
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 5000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestScoreFollowsNewCards(t *testing.T) {
+	p := &Player{}
+	p.ReceivePrivateCard(Card{rank: 5, suit: "♠"})
+	p.ReceivePublicCard(Card{rank: 5, suit: "♦"})
+	if p.PublicScore() != 5 || p.FinalScore() != 10 {
+		t.Fatalf("expected scores 5 and 10 but got %d and %d", p.PublicScore(), p.FinalScore())
+	}
+	p.ReceivePublicCard(Card{rank: 5, suit: "♣"})
+	if p.PublicScore() != 10 || p.FinalScore() != 45 {
+		t.Errorf("expected scores 10 and 45 after a new card but got %d and %d", p.PublicScore(), p.FinalScore())
+	}
+	p.ClearHand()
+	if p.PublicScore() != 0 || p.FinalScore() != 0 {
+		t.Errorf("expected no score after clearing the hand.")
+	}
+}
+
+func TestScoreFollowsRulesChangedInPlace(t *testing.T) {
+	rules := DefaultRules()
+	p := &Player{rules: &rules}
+	p.ReceivePrivateCard(Card{rank: 5, suit: "♠"})
+	p.ReceivePublicCard(Card{rank: 5, suit: "♦"})
+	p.ReceivePublicCard(Card{rank: 5, suit: "♣"})
+	if p.FinalScore() != 45 {
+		t.Fatalf("expected a three a kind of 5s to score 45 but got:%d", p.FinalScore())
+	}
+	rules.ThreeKindBonus = 50
+	if p.FinalScore() != 65 || p.PublicScore() != 10 {
+		t.Errorf("expected scores 10 and 65 under the changed rules but got %d and %d", p.PublicScore(), p.FinalScore())
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+
+	"golang.org/x/net/websocket"
+)
+
+// Client is a player's connection to a table.
+type Client struct {
+	conn *websocket.Conn
+	Id   string
+}
+
+// Dial connects to a table, e.g. ws://localhost:8080/tables/1, and joins it as name.
+func Dial(url, name string) (*Client, error) {
+	conn, err := websocket.Dial(url, "", "http://localhost/")
+	if err != nil {
+		return nil, err
+	}
+	if err := websocket.JSON.Send(conn, Message{Type: TypeJoin, Name: name}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	var m Message
+	if err := websocket.JSON.Receive(conn, &m); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if m.Type != TypeWelcome {
+		conn.Close()
+		return nil, errors.New(m.Error)
+	}
+	return &Client{conn: conn, Id: m.Name}, nil
+}
+
+// Receive waits for the next message from the table.
+func (c *Client) Receive() (Message, error) {
+	var m Message
+	err := websocket.JSON.Receive(c.conn, &m)
+	return m, err
+}
+
+// Call answers a TypeCall message.
+func (c *Client) Call(points int) error {
+	return websocket.JSON.Send(c.conn, Message{Type: TypeCall, Points: points})
+}
+
+// InOrOut answers a TypeInOrOut message.
+func (c *Client) InOrOut(in bool) error {
+	return websocket.JSON.Send(c.conn, Message{Type: TypeInOrOut, In: in})
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
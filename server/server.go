@@ -0,0 +1,367 @@
+// Package server hosts douji tables where every player plays from their own WebSocket connection.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"douji"
+
+	"golang.org/x/net/websocket"
+)
+
+// Message types sent over a player's connection.
+const (
+	TypeJoin    = "join"    // client to server: join a table as Name.
+	TypeWelcome = "welcome" // server to client: joined the table, Name is the player id.
+	TypeCall    = "call"    // server asks for a call in [0, Step...End] (plus 2*End when Last); client replies with Points.
+	TypeInOrOut = "inOrOut" // server asks whether to stay in on a call of Points; client replies with In.
+	TypeEvent   = "event"   // server to client: a game event of Kind.
+	TypeSetOver = "setOver" // server to client: the set is finished, the connection is closed afterwards.
+	TypeError   = "error"   // server to client: the last message was rejected.
+	TypeTimeout = "timeout" // server to client: the last decision took too long and the default was taken.
+)
+
+const writeTimeout = 10 * time.Second
+
+// Message is the JSON message exchanged between a table and a player's connection.
+// When the server asks for a decision, Points is the player's own points for a call or the calling points for in or out,
+// and Hidden and Public hold the asked player's own cards.
+// Other players' hidden cards are never sent; in a CardDealt event they have rank 0.
+// Neither are the deck seeds, from which every card can be worked out: GameStarted has a seed of 0 and the seeds of
+// every game are only sent with setOver.
+type Message struct {
+	Type    string                `json:"type"`
+	Name    string                `json:"name,omitempty"`
+	Points  int                   `json:"points"`
+	In      bool                  `json:"in,omitempty"`
+	Step    int                   `json:"step,omitempty"`
+	End     int                   `json:"end,omitempty"`
+	Last    bool                  `json:"last,omitempty"`
+	Options []int                 `json:"options,omitempty"` // the calls a calling player can choose from.
+	Pot     int                   `json:"pot,omitempty"`
+	Round   int                   `json:"round,omitempty"`
+	Kind    string                `json:"kind,omitempty"`
+	Event   json.RawMessage       `json:"event,omitempty"`
+	Hidden  []douji.Card          `json:"hidden,omitempty"`
+	Public  []douji.Card          `json:"public,omitempty"`
+	Error   string                `json:"error,omitempty"`
+	Score   *douji.ScoreBreakdown `json:"score,omitempty"` // how the asked player's own cards score.
+	Seeds   []int64               `json:"seeds,omitempty"` // the deck seed of every game, sent once the set is over.
+}
+
+// Server hosts tables at /tables/{id}.
+type Server struct {
+	db     douji.Db
+	mu     sync.Mutex
+	tables map[string]*Table
+}
+
+func NewServer(db douji.Db) *Server {
+	return &Server{db: db, tables: map[string]*Table{}}
+}
+
+// NewTable opens a table which starts its set as soon as every seat is taken.
+func (s *Server) NewTable(id string, cfg TableConfig) (*Table, error) {
+	if cfg.Seats < 2 {
+		return nil, errors.New("a table needs at least two seats")
+	}
+	if cfg.Rules == (douji.RuleSet{}) {
+		cfg.Rules = douji.DefaultRules()
+	}
+	if err := cfg.Rules.Validate(); err != nil {
+		return nil, err
+	}
+	if max := cfg.Rules.MaxPlayers(cfg.HiddenCount); cfg.Seats > max {
+		return nil, fmt.Errorf("%d decks can serve at most %d seats but got:%d", cfg.Rules.Decks, max, cfg.Seats)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tables[id]; ok {
+		return nil, fmt.Errorf("table %s already exists", id)
+	}
+	t := &Table{id: id, cfg: cfg, db: s.db, done: make(chan struct{})}
+	s.tables[id] = t
+	return t, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/tables/")
+	s.mu.Lock()
+	t, ok := s.tables[id]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	websocket.Handler(t.serveConn).ServeHTTP(w, r)
+}
+
+// TableConfig is how a table plays its set.
+type TableConfig struct {
+	Seats       int // number of players needed to start.
+	Games       int
+	Base        int
+	HiddenCount int
+	// CallTimeout and InOrOutTimeout bound each decision; a player who doesn't answer in time calls 0 or goes out. Zero waits forever.
+	CallTimeout    time.Duration
+	InOrOutTimeout time.Duration
+	Rules          douji.RuleSet // the zero value plays by the default rules.
+}
+
+// Table runs one set between the players connected to it; it implements douji.MiddleGame by asking each player over their own connection.
+type Table struct {
+	id      string
+	cfg     TableConfig
+	db      douji.Db
+	mu      sync.Mutex
+	seats   []*seat
+	started bool
+	done    chan struct{}
+	err     error   // the games of the set which couldn't be saved.
+	seeds   []int64 // the deck seed of every game played, only sent once the set is over.
+}
+
+type seat struct {
+	player  *douji.Player
+	conn    *websocket.Conn
+	sendMu  sync.Mutex
+	replies chan Message
+}
+
+func (s *seat) send(m Message) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return websocket.JSON.Send(s.conn, m)
+}
+
+// Done is closed once the table's set is finished.
+func (t *Table) Done() <-chan struct{} {
+	return t.done
+}
+
+// Err returns the games of the table's set which couldn't be saved, it's only set once Done is closed.
+func (t *Table) Err() error {
+	return t.err
+}
+
+func (t *Table) serveConn(ws *websocket.Conn) {
+	defer ws.Close()
+	var join Message
+	if err := websocket.JSON.Receive(ws, &join); err != nil || join.Type != TypeJoin {
+		websocket.JSON.Send(ws, Message{Type: TypeError, Error: "expected to join the table first"})
+		return
+	}
+	s, err := t.sit(join.Name, ws)
+	if err != nil {
+		websocket.JSON.Send(ws, Message{Type: TypeError, Error: err.Error()})
+		return
+	}
+	defer func() {
+		t.leave(s)
+		close(s.replies) // a disconnected player quits every decision afterwards.
+	}()
+	s.send(Message{Type: TypeWelcome, Name: s.player.Id()})
+	t.startIfFull()
+	for {
+		var m Message
+		if err := websocket.JSON.Receive(ws, &m); err != nil {
+			return
+		}
+		select {
+		case s.replies <- m:
+		case <-t.done:
+			return
+		}
+	}
+}
+
+func (t *Table) sit(name string, ws *websocket.Conn) (*seat, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.started {
+		return nil, errors.New("table is full")
+	}
+	for _, s := range t.seats {
+		if s.player.Name == name {
+			return nil, fmt.Errorf("%s is already at the table", name)
+		}
+	}
+	p, err := t.db.LoadPlayerStatsByName(name)
+	if err != nil {
+		return nil, err
+	}
+	s := &seat{player: p, conn: ws, replies: make(chan Message, 1)}
+	t.seats = append(t.seats, s)
+	return s, nil
+}
+
+// leave frees the seat of a player who disconnects before the set starts, so they or someone else can take it.
+// Once the set has started the seat is played to the end of the set.
+func (t *Table) leave(s *seat) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.started {
+		return
+	}
+	for i, seated := range t.seats {
+		if seated == s {
+			t.seats = append(t.seats[:i], t.seats[i+1:]...)
+			return
+		}
+	}
+}
+
+func (t *Table) startIfFull() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.started || len(t.seats) < t.cfg.Seats {
+		return
+	}
+	t.started = true
+	go t.run()
+}
+
+func (t *Table) run() {
+	players := make([]*douji.Player, len(t.seats))
+	for i, s := range t.seats {
+		players[i] = s.player
+	}
+	set, err := douji.NewSet(t.cfg.Games, false, t.cfg.Rules) // NewTable has validated the rules already.
+	if err == nil {
+		set.SetEventSink(douji.EventSinkFunc(t.broadcast))
+		set.SetDecisionTimeouts(douji.DecisionTimeouts{Call: t.cfg.CallTimeout, InOrOut: t.cfg.InOrOutTimeout})
+		err = set.Run(players, t, t.db, t.cfg.Base, t.cfg.HiddenCount, 0)
+	}
+	t.err = err
+	for _, s := range t.seats {
+		s.send(Message{Type: TypeSetOver, Points: s.player.Points(), Seeds: t.seeds})
+	}
+	close(t.done)
+	for _, s := range t.seats {
+		s.conn.Close()
+	}
+}
+
+// broadcast sends an event to every player, hiding other players' hidden cards and the deck seed until the set is over.
+func (t *Table) broadcast(e douji.Event) {
+	if gs, ok := e.(douji.GameStarted); ok {
+		t.seeds = append(t.seeds, gs.Seed)
+		gs.Seed = 0
+		e = gs
+	}
+	for _, s := range t.seats {
+		ev := e
+		if cd, ok := e.(douji.CardDealt); ok && cd.Hidden && cd.PlayerId != s.player.Id() {
+			cd.Card = douji.Card{}
+			ev = cd
+		}
+		data, err := json.Marshal(ev)
+		if err != nil {
+			continue
+		}
+		s.send(Message{Type: TypeEvent, Kind: e.Kind(), Event: data})
+	}
+}
+
+func (t *Table) seatOf(v douji.TableView) *seat {
+	for _, s := range t.seats {
+		if s.player.Id() == v.Self().Id {
+			return s
+		}
+	}
+	panic(fmt.Errorf("player %s is not at table %s", v.Self().Id, t.id))
+}
+
+func scoreOf(v douji.TableView) *douji.ScoreBreakdown {
+	b := v.Breakdown()
+	return &b
+}
+
+func validCall(points int, options []int) bool {
+	for _, o := range options {
+		if points == o {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Table) CallOnce(v douji.TableView, step, end int, lastCall bool) int {
+	points, _ := t.CallOnceContext(context.Background(), v, step, end, lastCall)
+	return points
+}
+
+func (t *Table) InOrOut(v douji.TableView, callingChip int) bool {
+	in, _ := t.InOrOutContext(context.Background(), v, callingChip)
+	return in
+}
+
+func (t *Table) CallOnceContext(ctx context.Context, v douji.TableView, step, end int, lastCall bool) (int, error) {
+	s := t.seatOf(v)
+	s.drain()
+	for {
+		s.send(Message{Type: TypeCall, Step: step, End: end, Last: lastCall, Options: v.CallOptions(), Points: v.Self().Points, Pot: v.Pot(), Round: v.Round(), Hidden: v.HiddenCards(), Public: v.Self().PublicCards(), Score: scoreOf(v)})
+		m, err := s.reply(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if m.Type == TypeCall && validCall(m.Points, v.CallOptions()) {
+			return m.Points, nil
+		}
+		s.send(Message{Type: TypeError, Error: fmt.Sprintf("invalid call:%d", m.Points)})
+	}
+}
+
+func (t *Table) InOrOutContext(ctx context.Context, v douji.TableView, callingChip int) (bool, error) {
+	s := t.seatOf(v)
+	s.drain()
+	for {
+		s.send(Message{Type: TypeInOrOut, Points: callingChip, Pot: v.Pot(), Round: v.Round(), Hidden: v.HiddenCards(), Public: v.Self().PublicCards(), Score: scoreOf(v)})
+		m, err := s.reply(ctx)
+		if err != nil {
+			return false, err
+		}
+		if m.Type == TypeInOrOut {
+			return m.In, nil
+		}
+		s.send(Message{Type: TypeError, Error: "expected in or out"})
+	}
+}
+
+var errDisconnected = errors.New("player disconnected")
+
+// reply waits for the player's answer until ctx is done.
+func (s *seat) reply(ctx context.Context) (Message, error) {
+	select {
+	case m, ok := <-s.replies:
+		if !ok {
+			return m, errDisconnected // disconnected player quits every decision.
+		}
+		return m, nil
+	case <-ctx.Done():
+		s.send(Message{Type: TypeTimeout})
+		return Message{}, ctx.Err()
+	}
+}
+
+// drain drops any late answer to a decision which has already timed out.
+func (s *seat) drain() {
+	for {
+		select {
+		case _, ok := <-s.replies:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
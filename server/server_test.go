@@ -0,0 +1,210 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"douji"
+)
+
+type testDb struct{}
+
+func (testDb) SaveGameStats(setId string, gameId int, seed int64, pot douji.PotStats, pnp []douji.PlayerDTO) error {
+	return nil
+}
+
+func (testDb) LoadPlayerStatsByName(name string) (*douji.Player, error) {
+	return douji.NewTestPlayer(name, name, 100), nil
+}
+
+func (testDb) CreatePlayer(name, password string, points int) (string, error) {
+	return name, nil
+}
+
+func startTable(t *testing.T, cfg TableConfig) (*Table, string) {
+	srv := NewServer(testDb{})
+	table, err := srv.NewTable("1", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return table, "ws" + strings.TrimPrefix(ts.URL, "http") + "/tables/1"
+}
+
+// play answers every call with step and stays in, checking it never sees another player's hidden card.
+// It returns the player's points once the set is over and how many events of each kind it saw.
+func play(t *testing.T, c *Client) (int, map[string]int) {
+	kinds := map[string]int{}
+	for {
+		m, err := c.Receive()
+		if err != nil {
+			t.Errorf("%s: unexpected error before the set is over:%v", c.Id, err)
+			return 0, kinds
+		}
+		switch m.Type {
+		case TypeCall:
+			c.Call(m.Step)
+		case TypeInOrOut:
+			c.InOrOut(true)
+		case TypeEvent:
+			kinds[m.Kind]++
+			if m.Kind == "GameStarted" {
+				var gs douji.GameStarted
+				if err := json.Unmarshal(m.Event, &gs); err != nil || gs.Seed != 0 {
+					t.Errorf("%s: saw the deck seed %d before the set is over, %v", c.Id, gs.Seed, err)
+				}
+			}
+			if m.Kind != "CardDealt" {
+				continue
+			}
+			var cd struct {
+				PlayerId string
+				Card     douji.Card
+				Hidden   bool
+			}
+			if err := json.Unmarshal(m.Event, &cd); err != nil {
+				t.Errorf("%s: failed to decode CardDealt:%v", c.Id, err)
+			}
+			if cd.Hidden && cd.PlayerId != c.Id && cd.Card.Rank() != 0 {
+				t.Errorf("%s: saw %s's hidden card %v", c.Id, cd.PlayerId, cd.Card)
+			}
+			if cd.Hidden && cd.PlayerId == c.Id && cd.Card.Rank() == 0 {
+				t.Errorf("%s: didn't see its own hidden card", c.Id)
+			}
+		case TypeSetOver:
+			if len(m.Seeds) == 0 || m.Seeds[0] == 0 {
+				t.Errorf("%s: expected the deck seeds once the set is over but got:%v", c.Id, m.Seeds)
+			}
+			return m.Points, kinds
+		}
+	}
+}
+
+func TestTablePlaysSetWithRemotePlayers(t *testing.T) {
+	table, url := startTable(t, TableConfig{Seats: 3, Games: 3, Base: 1, HiddenCount: 2})
+	var wg sync.WaitGroup
+	points := make([]int, 3)
+	for i, name := range []string{"Liu", "Wang", "Gu"} {
+		c, err := Dial(url, name)
+		if err != nil {
+			t.Fatalf("failed to join the table as %s:%v", name, err)
+		}
+		defer c.Close()
+		wg.Add(1)
+		go func(i int, c *Client) {
+			defer wg.Done()
+			points[i], _ = play(t, c)
+		}(i, c)
+	}
+	wg.Wait()
+	<-table.Done()
+	total := points[0] + points[1] + points[2]
+	if total > 300 {
+		t.Errorf("expected no points created but players ended with %v", points)
+	}
+}
+
+func TestTableRejectsDuplicateAndLateJoins(t *testing.T) {
+	_, url := startTable(t, TableConfig{Seats: 2, Games: 1, Base: 1, HiddenCount: 1})
+	c, err := Dial(url, "Liu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := Dial(url, "Liu"); err == nil {
+		t.Errorf("expected joining twice with the same name to fail.")
+	}
+	c2, err := Dial(url, "Wang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	if _, err := Dial(url, "Gu"); err == nil {
+		t.Errorf("expected joining a full table to fail.")
+	}
+}
+
+func TestDisconnectedPlayerQuits(t *testing.T) {
+	table, url := startTable(t, TableConfig{Seats: 2, Games: 1, Base: 1, HiddenCount: 1})
+	c, err := Dial(url, "Liu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	quitter, err := Dial(url, "Wang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quitter.Close()
+	_, kinds := play(t, c)
+	<-table.Done()
+	if kinds["DecisionFailed"] == 0 || kinds["DecisionTimedOut"] > 0 {
+		t.Errorf("expected the disconnected player's decisions to fail without timing out but saw:%v", kinds)
+	}
+}
+
+func TestPlayerLeavingBeforeTheSetStartsFreesTheSeat(t *testing.T) {
+	table, url := startTable(t, TableConfig{Seats: 2, Games: 1, Base: 1, HiddenCount: 1})
+	left, err := Dial(url, "Wang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	left.Close()
+	var back *Client
+	for deadline := time.Now().Add(5 * time.Second); back == nil; {
+		if back, err = Dial(url, "Wang"); err != nil && time.Now().After(deadline) {
+			t.Fatalf("expected Wang to rejoin once disconnected but got:%v", err)
+		}
+		time.Sleep(time.Millisecond) // the table may not have noticed Wang left yet.
+	}
+	defer back.Close()
+	c, err := Dial(url, "Liu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		play(t, back)
+	}()
+	_, kinds := play(t, c)
+	wg.Wait()
+	<-table.Done()
+	if kinds["DecisionFailed"] > 0 {
+		t.Errorf("expected no dead seat at the table but saw:%v", kinds)
+	}
+}
+
+func TestSilentPlayerTimesOut(t *testing.T) {
+	table, url := startTable(t, TableConfig{Seats: 2, Games: 2, Base: 1, HiddenCount: 1, CallTimeout: 20 * time.Millisecond, InOrOutTimeout: 20 * time.Millisecond})
+	c, err := Dial(url, "Liu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	silent, err := Dial(url, "Wang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+	go func() {
+		for {
+			if _, err := silent.Receive(); err != nil {
+				return // reads everything, answers nothing.
+			}
+		}
+	}()
+	play(t, c)
+	select {
+	case <-table.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the set to finish despite a silent player.")
+	}
+}
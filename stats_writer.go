@@ -0,0 +1,163 @@
+package douji
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StatsWriterConfig tunes a StatsWriter, the zero value writes by DefaultStatsWriterConfig.
+type StatsWriterConfig struct {
+	QueueSize int           // games waiting to be saved before Write blocks.
+	Retries   int           // retries of a failed save before giving up on it.
+	Backoff   time.Duration // wait before the first retry, doubling with every retry.
+}
+
+// DefaultStatsWriterConfig queues up to 16 games and retries a failed save 3 times over 0.7 seconds.
+func DefaultStatsWriterConfig() StatsWriterConfig {
+	return StatsWriterConfig{QueueSize: 16, Retries: 3, Backoff: 100 * time.Millisecond}
+}
+
+func (c StatsWriterConfig) orDefault() StatsWriterConfig {
+	if c == (StatsWriterConfig{}) {
+		return DefaultStatsWriterConfig()
+	}
+	return c
+}
+
+// StatsKey is the idempotency key of a player's stats after a game of a set.
+func StatsKey(setId string, gameId int, playerId string) string {
+	return fmt.Sprintf("%s/%d/%s", setId, gameId, playerId)
+}
+
+// StatsWriter saves games' stats to a Db in the background, one game after another in the order they're written.
+// Every player's stats are saved on their own and retried with backoff. Retries rely on the Db skipping the rows already saved
+// under their StatsKey, so a save which stored the row but failed anyway is never duplicated.
+type StatsWriter struct {
+	db      Db
+	cfg     StatsWriterConfig
+	queue   chan statsJob
+	stopped chan struct{}
+
+	mu     sync.RWMutex // held for reading while queueing, so Close never closes the queue under a Write.
+	closed bool
+
+	failedMu sync.Mutex
+	failed   map[string][]*SaveError // failed games by set id.
+}
+
+// statsJob is a game to save, or a flush marker when flushed is set.
+type statsJob struct {
+	setId   string
+	gameId  int
+	seed    int64
+	pot     PotStats
+	players []PlayerDTO
+	flushed chan struct{}
+}
+
+// NewStatsWriter starts a StatsWriter saving to db, which must be closed once done with.
+func NewStatsWriter(db Db, cfg StatsWriterConfig) *StatsWriter {
+	cfg = cfg.orDefault()
+	w := &StatsWriter{db: db, cfg: cfg, queue: make(chan statsJob, cfg.QueueSize), stopped: make(chan struct{}),
+		failed: map[string][]*SaveError{}}
+	go w.run()
+	return w
+}
+
+// Write queues a game's stats to be saved, blocking while the queue is full. Like sending on a closed channel,
+// writing to a closed StatsWriter panics.
+func (w *StatsWriter) Write(setId string, gameId int, seed int64, pot PotStats, players []PlayerDTO) {
+	w.enqueue(statsJob{setId: setId, gameId: gameId, seed: seed, pot: pot, players: append([]PlayerDTO(nil), players...)})
+}
+
+// Flush waits for every game written so far to be saved or given up on, and returns the games of setId which couldn't be saved
+// since the last flush as a *SetError.
+func (w *StatsWriter) Flush(setId string) error {
+	w.mu.RLock()
+	if !w.closed {
+		done := make(chan struct{})
+		w.queue <- statsJob{flushed: done}
+		w.mu.RUnlock()
+		<-done
+	} else {
+		w.mu.RUnlock()
+		<-w.stopped
+	}
+	w.failedMu.Lock()
+	defer w.failedMu.Unlock()
+	failed := w.failed[setId]
+	delete(w.failed, setId)
+	if len(failed) == 0 {
+		return nil
+	}
+	return &SetError{SetId: setId, Failed: failed}
+}
+
+// Close stops taking writes and waits for the games already written to be saved. Their failures can still be flushed.
+func (w *StatsWriter) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+	<-w.stopped
+}
+
+func (w *StatsWriter) enqueue(job statsJob) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		panic("write to a closed StatsWriter")
+	}
+	w.queue <- job
+}
+
+func (w *StatsWriter) run() {
+	defer close(w.stopped)
+	for job := range w.queue {
+		if job.flushed != nil {
+			close(job.flushed)
+			continue
+		}
+		w.save(job)
+	}
+}
+
+// save saves the stats of every player of a game, recording the game as failed when any of them can't be.
+func (w *StatsWriter) save(job statsJob) {
+	var err error
+	for _, p := range job.players {
+		if perr := w.retry(job, p); perr != nil {
+			err = perr
+		}
+	}
+	if err != nil {
+		w.failedMu.Lock()
+		w.failed[job.setId] = append(w.failed[job.setId], &SaveError{SetId: job.setId, GameId: job.gameId, Err: err})
+		w.failedMu.Unlock()
+	}
+}
+
+// retry saves a player's stats, retrying with backoff, and returns the last error once it gives up.
+func (w *StatsWriter) retry(job statsJob, p PlayerDTO) error {
+	backoff := w.cfg.Backoff
+	err := w.saveOnce(job, p)
+	for i := 0; err != nil && i < w.cfg.Retries; i++ {
+		time.Sleep(backoff)
+		backoff *= 2
+		err = w.saveOnce(job, p)
+	}
+	return err
+}
+
+// saveOnce saves a player's stats, turning a panicking Db into an error so it can't take the writer down.
+func (w *StatsWriter) saveOnce(job statsJob, p PlayerDTO) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("db panicked: %v", r)
+		}
+	}()
+	return w.db.SaveGameStats(job.setId, job.gameId, job.seed, job.pot, []PlayerDTO{p})
+}
@@ -0,0 +1,181 @@
+package douji
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rowsDb keeps every row saved in order, skipping the ones already saved like every Db, and failing a key's first failures[key] saves.
+type rowsDb struct {
+	recordingDb
+	mu       sync.Mutex
+	rows     []string
+	attempts map[string]int
+	failures map[string]int
+	block    chan struct{} // when set, every save waits for it.
+}
+
+func (db *rowsDb) SaveGameStats(setId string, gameId int, seed int64, pot PotStats, pnp []PlayerDTO) error {
+	if db.block != nil {
+		<-db.block
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.attempts == nil {
+		db.attempts = map[string]int{}
+	}
+	for _, p := range pnp {
+		key := StatsKey(setId, gameId, p.Id)
+		db.attempts[key]++
+		if contains(db.rows, key) {
+			continue
+		}
+		if db.attempts[key] <= db.failures[key] {
+			return errStorageDown
+		}
+		db.rows = append(db.rows, key)
+	}
+	return nil
+}
+
+var fastRetries = StatsWriterConfig{QueueSize: 2, Retries: 2, Backoff: time.Millisecond}
+
+func TestStatsWriterKeepsOrder(t *testing.T) {
+	db := &rowsDb{}
+	w := NewStatsWriter(db, fastRetries)
+	defer w.Close()
+	var want []string
+	for g := 1; g <= 20; g++ {
+		w.Write("s", g, 0, PotStats{}, []PlayerDTO{{Id: "a"}, {Id: "b"}})
+		want = append(want, StatsKey("s", g, "a"), StatsKey("s", g, "b"))
+	}
+	if err := w.Flush("s"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(db.rows, want) {
+		t.Errorf("expected rows in the order they were written but got:%v", db.rows)
+	}
+}
+
+func TestStatsWriterRetriesWithoutDuplicates(t *testing.T) {
+	db := &rowsDb{failures: map[string]int{StatsKey("s", 1, "b"): 2}}
+	w := NewStatsWriter(db, fastRetries)
+	defer w.Close()
+	w.Write("s", 1, 0, PotStats{}, []PlayerDTO{{Id: "a"}, {Id: "b"}})
+	w.Write("s", 1, 0, PotStats{}, []PlayerDTO{{Id: "a"}, {Id: "b"}}) // written twice, saved once.
+	if err := w.Flush("s"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{StatsKey("s", 1, "a"), StatsKey("s", 1, "b")}
+	if !reflect.DeepEqual(db.rows, want) {
+		t.Errorf("expected every row saved once but got:%v", db.rows)
+	}
+	// a is saved once per write; b fails twice, is saved on the second retry and skipped by the second write.
+	if db.attempts[StatsKey("s", 1, "a")] != 2 || db.attempts[StatsKey("s", 1, "b")] != 4 {
+		t.Errorf("expected only the failed row to be retried but got:%v", db.attempts)
+	}
+}
+
+func TestStatsWriterGivesUp(t *testing.T) {
+	db := &rowsDb{failures: map[string]int{StatsKey("s", 2, "a"): 10}}
+	w := NewStatsWriter(db, fastRetries)
+	for g := 1; g <= 3; g++ {
+		w.Write("s", g, 0, PotStats{}, []PlayerDTO{{Id: "a"}})
+	}
+	w.Write("other", 1, 0, PotStats{}, []PlayerDTO{{Id: "a"}})
+	w.Close()
+	err := w.Flush("s")
+	var setErr *SetError
+	if !errors.As(err, &setErr) || len(setErr.Failed) != 1 || setErr.Failed[0].GameId != 2 || !errors.Is(err, errStorageDown) {
+		t.Fatalf("expected game 2 to fail after its retries but got:%v", err)
+	}
+	if db.attempts[StatsKey("s", 2, "a")] != 3 || len(db.rows) != 3 {
+		t.Errorf("expected 3 attempts of game 2 and every other game saved but got:%v, %v", db.attempts, db.rows)
+	}
+	if err := w.Flush("s"); err != nil {
+		t.Errorf("expected failures to be flushed once but got:%v", err)
+	}
+}
+
+func TestStatsWriterQueueIsBounded(t *testing.T) {
+	db := &rowsDb{block: make(chan struct{})}
+	w := NewStatsWriter(db, StatsWriterConfig{QueueSize: 1, Retries: 0, Backoff: time.Millisecond})
+	written := make(chan int, 3)
+	go func() {
+		for g := 1; g <= 3; g++ {
+			w.Write("s", g, 0, PotStats{}, []PlayerDTO{{Id: "a"}})
+			written <- g
+		}
+	}()
+	// game 1 is being saved and game 2 is queued, so writing game 3 blocks.
+	for _, want := range []int{1, 2} {
+		if g := <-written; g != want {
+			t.Fatalf("expected game %d to be written but got:%d", want, g)
+		}
+	}
+	select {
+	case <-written:
+		t.Errorf("expected writing past a full queue to block.")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(db.block)
+	<-written
+	w.Close()
+	if len(db.rows) != 3 {
+		t.Errorf("expected every game saved once unblocked but got:%v", db.rows)
+	}
+}
+
+func TestStatsWriterWriteAfterClose(t *testing.T) {
+	w := NewStatsWriter(&rowsDb{}, fastRetries)
+	w.Close()
+	w.Close()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected writing to a closed StatsWriter to panic.")
+		}
+	}()
+	w.Write("s", 1, 0, PotStats{}, nil)
+}
+
+// lostReplyDb stores every row but fails the first save of each, like a request timing out after storage stored the row.
+type lostReplyDb struct {
+	Db
+	mu     sync.Mutex
+	failed map[string]bool
+}
+
+func (db *lostReplyDb) SaveGameStats(setId string, gameId int, seed int64, pot PotStats, pnp []PlayerDTO) error {
+	if err := db.Db.SaveGameStats(setId, gameId, seed, pot, pnp); err != nil {
+		return err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, p := range pnp {
+		if key := StatsKey(setId, gameId, p.Id); !db.failed[key] {
+			db.failed[key] = true
+			return errStorageDown
+		}
+	}
+	return nil
+}
+
+func TestStatsWriterRetriesStoredRowOnce(t *testing.T) {
+	dir := t.TempDir()
+	w := NewStatsWriter(&lostReplyDb{Db: NewCSVIn(dir), failed: map[string]bool{}}, fastRetries)
+	for g := 1; g <= 2; g++ {
+		w.Write("s", g, 0, PotStats{}, []PlayerDTO{{"1", "Liu", 100 - g}, {"2", "Wang", 100 + g}})
+	}
+	if err := w.Flush("s"); err != nil {
+		t.Fatalf("expected the retries to succeed but got:%v", err)
+	}
+	w.Close()
+	rows, err := readRows(filepath.Join(dir, "douji.csv"), statsHeader)
+	if err != nil || len(rows) != 4 {
+		t.Errorf("expected every row stored once despite the retries but got %d rows, %v", len(rows), err)
+	}
+}
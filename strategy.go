@@ -0,0 +1,116 @@
+package douji
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoStrategy is returned for a player a Dispatcher has neither a seated strategy nor a fallback for.
+var ErrNoStrategy = errors.New("no strategy seated")
+
+// Strategy makes the decisions of a player, whether it asks a human or is a bot.
+type Strategy interface {
+	MiddleGame
+	// Name identifies the strategy, e.g. in tournament reports.
+	Name() string
+}
+
+// Dispatcher is a MiddleGame routing each player's decisions to the strategy seated for them, so humans and bots can play at one table.
+type Dispatcher struct {
+	fallback MiddleGame
+	seats    map[string]MiddleGame
+}
+
+// NewDispatcher creates a Dispatcher asking fallback for every player without a seated strategy; fallback can be nil when every player is seated,
+// a game with a player who isn't then refuses to start.
+func NewDispatcher(fallback MiddleGame) *Dispatcher {
+	return &Dispatcher{fallback: fallback, seats: map[string]MiddleGame{}}
+}
+
+// Seat routes all decisions of a player to md.
+func (d *Dispatcher) Seat(playerId string, md MiddleGame) {
+	d.seats[playerId] = md
+}
+
+// StrategyOf returns who makes the decisions of a player. Without a seated strategy or a fallback,
+// the player takes the default action, calling 0 or going out, and a ContextMiddleGame decision fails with ErrNoStrategy.
+func (d *Dispatcher) StrategyOf(playerId string) MiddleGame {
+	if md, ok := d.seats[playerId]; ok {
+		return md
+	}
+	if d.fallback == nil {
+		return unseated{playerId}
+	}
+	return d.fallback
+}
+
+// SeatChecker is a MiddleGame which can tell before a game is dealt whether it decides for every player of the game.
+// A game played through a SeatChecker refuses to start on its error; MiddleGames wrapping another one forward the check.
+type SeatChecker interface {
+	CheckSeats(players []*Player) error
+}
+
+// checkSeats checks md decides for every player when it's a SeatChecker.
+func checkSeats(md MiddleGame, players []*Player) error {
+	if sc, ok := md.(SeatChecker); ok {
+		return sc.CheckSeats(players)
+	}
+	return nil
+}
+
+// CheckSeats returns ErrNoStrategy for the first player without a seated strategy when there's no fallback.
+func (d *Dispatcher) CheckSeats(players []*Player) error {
+	if d.fallback != nil {
+		return nil
+	}
+	for _, p := range players {
+		if _, ok := d.seats[p.id]; !ok {
+			return fmt.Errorf("%w for player id:%s", ErrNoStrategy, p.id)
+		}
+	}
+	return nil
+}
+
+// unseated takes the default action for a player without a strategy.
+type unseated struct {
+	playerId string
+}
+
+func (u unseated) CallOnce(view TableView, step, end int, lastCall bool) int { return 0 }
+
+func (u unseated) InOrOut(view TableView, callingChip int) bool { return false }
+
+func (u unseated) CallOnceContext(ctx context.Context, view TableView, step, end int, lastCall bool) (int, error) {
+	return 0, fmt.Errorf("%w for player id:%s", ErrNoStrategy, u.playerId)
+}
+
+func (u unseated) InOrOutContext(ctx context.Context, view TableView, callingChip int) (bool, error) {
+	return false, fmt.Errorf("%w for player id:%s", ErrNoStrategy, u.playerId)
+}
+
+func (d *Dispatcher) CallOnce(view TableView, step, end int, lastCall bool) int {
+	return d.StrategyOf(view.Self().Id).CallOnce(view, step, end, lastCall)
+}
+
+func (d *Dispatcher) InOrOut(view TableView, callingChip int) bool {
+	return d.StrategyOf(view.Self().Id).InOrOut(view, callingChip)
+}
+
+// CallOnceContext only lets the decision time out when the player's strategy is a ContextMiddleGame.
+func (d *Dispatcher) CallOnceContext(ctx context.Context, view TableView, step, end int, lastCall bool) (int, error) {
+	md := d.StrategyOf(view.Self().Id)
+	if cmd, ok := md.(ContextMiddleGame); ok {
+		return cmd.CallOnceContext(ctx, view, step, end, lastCall)
+	}
+	return md.CallOnce(view, step, end, lastCall), nil
+}
+
+// InOrOutContext only lets the decision time out when the player's strategy is a ContextMiddleGame.
+func (d *Dispatcher) InOrOutContext(ctx context.Context, view TableView, callingChip int) (bool, error) {
+	md := d.StrategyOf(view.Self().Id)
+	if cmd, ok := md.(ContextMiddleGame); ok {
+		return cmd.InOrOutContext(ctx, view, callingChip)
+	}
+	return md.InOrOut(view, callingChip), nil
+}
@@ -0,0 +1,156 @@
+package douji
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fixedStrategy struct {
+	name string
+	call int
+	in   bool
+}
+
+func (s fixedStrategy) Name() string                                              { return s.name }
+func (s fixedStrategy) CallOnce(view TableView, step, end int, lastCall bool) int { return s.call }
+func (s fixedStrategy) InOrOut(view TableView, callingChip int) bool              { return s.in }
+
+func TestDispatcher(t *testing.T) {
+	players := getFourTestingPlayers()
+	g := &Game{seated: players, players: players}
+	d := NewDispatcher(fixedStrategy{name: "human", call: 1, in: true})
+	d.Seat("1", fixedStrategy{name: "bot", call: 3, in: false})
+
+	if got := d.CallOnce(g.view(players[0]), 1, 5, false); got != 1 {
+		t.Errorf("expected an unseated player to be asked through the fallback but got call:%d", got)
+	}
+	if got := d.CallOnce(g.view(players[1]), 1, 5, false); got != 3 {
+		t.Errorf("expected a seated player to be asked through its strategy but got call:%d", got)
+	}
+	if d.InOrOut(g.view(players[1]), 1) {
+		t.Errorf("expected a seated player to be asked through its strategy for in or out.")
+	}
+	if _, err := d.CallOnceContext(context.Background(), g.view(players[1]), 1, 5, false); err != nil {
+		t.Errorf("expected a plain strategy to never time out but got:%v", err)
+	}
+
+	d.Seat("2", Contextual(stuckMiddleGame{}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := d.InOrOutContext(ctx, g.view(players[2]), 1); err == nil {
+		t.Errorf("expected a context strategy to time out.")
+	}
+
+	if points, err := NewDispatcher(nil).CallOnceContext(context.Background(), g.view(players[0]), 1, 5, false); points != 0 || !errors.Is(err, ErrNoStrategy) {
+		t.Errorf("expected an unseated player without a fallback to call 0 with ErrNoStrategy but got:%d, %v", points, err)
+	}
+	unseated := NewDispatcher(nil)
+	unseated.Seat("0", fixedStrategy{name: "human", call: 1, in: true})
+	s := newTestSet(1, DefaultRules())
+	if err := s.Run(getFourTestingPlayers(), unseated, &recordingDb{}, 1, 1, 0); !errors.Is(err, ErrNoStrategy) {
+		t.Errorf("expected a set with unseated players to fail with ErrNoStrategy but got:%v", err)
+	}
+	if err := s.Run(getFourTestingPlayers(), Contextual(unseated), &recordingDb{}, 1, 1, 0); !errors.Is(err, ErrNoStrategy) {
+		t.Errorf("expected a wrapped Dispatcher to check its seats too but got:%v", err)
+	}
+}
+
+func TestBotsPlayASet(t *testing.T) {
+	players := getFourTestingPlayers()
+	d := NewDispatcher(nil)
+	d.Seat("0", NewRandomBot(1))
+	d.Seat("1", ThresholdBot{Fold: 10, Raise: 20})
+	d.Seat("2", PotOddsBot{})
+	d.Seat("3", NewRandomBot(2))
+	log := &EventLog{}
+	s := newTestSet(20, DefaultRules())
+	s.SetSeed(9)
+	s.SetEventSink(log)
+	s.Run(players, d, &recordingDb{}, 1, 2, 0)
+
+	total, pot := 0, 0
+	for _, p := range players {
+		total += p.points
+	}
+	events := log.Events()
+	if pb, ok := events[len(events)-1].(PotBombed); ok {
+		pot = pb.Pot
+	}
+	if total+pot != 400 {
+		t.Errorf("expected points to be conserved but players have %d with %d left in the pot", total, pot)
+	}
+	for _, e := range events {
+		if c, ok := e.(Called); ok && c.Points < 0 {
+			t.Errorf("expected bots to call from the ladder but got:%d", c.Points)
+		}
+	}
+}
+
+func TestThresholdBot(t *testing.T) {
+	me := &Player{id: "0", points: 100, Hand: Hand{privateCards: []Card{{rank: 15}}, publicCards: []Card{{rank: 13}}}}
+	op := &Player{id: "1", points: 100, Hand: Hand{privateCards: []Card{{rank: 3}}, publicCards: []Card{{rank: 4}}}}
+	g := &Game{seated: []*Player{me, op}, players: []*Player{me, op}, step: 1, end: 5, round: 4, maxRound: 4}
+	b := ThresholdBot{Fold: 5, Raise: 20}
+	if got := b.CallOnce(g.view(me), 1, 5, true); got != 10 {
+		t.Errorf("expected a far ahead bot to call 10 in the final round but got:%d", got)
+	}
+	if got := b.CallOnce(g.view(op), 1, 5, true); got != 0 {
+		t.Errorf("expected a far behind bot to quit but got:%d", got)
+	}
+	if b.InOrOut(g.view(op), 1) {
+		t.Errorf("expected a far behind bot to go out.")
+	}
+	me.points = 4
+	if got := b.CallOnce(g.view(me), 1, 5, true); got != 4 {
+		t.Errorf("expected a far ahead bot short of points to go all-in with 4 but got:%d", got)
+	}
+	g.round = 1
+	if got := (ThresholdBot{Fold: 5, Raise: 50}).CallOnce(g.view(me), 1, 5, false); got != 1 {
+		t.Errorf("expected a bot short of the end to call the step but got:%d", got)
+	}
+}
+
+func TestPotOddsBot(t *testing.T) {
+	p := &Player{id: "0", points: 100, Hand: Hand{privateCards: []Card{{rank: 10}}}}
+	op := &Player{id: "1", points: 100}
+	g := &Game{seated: []*Player{p, op}, players: []*Player{p, op}, pot: 10, step: 1, end: 5, round: 4, maxRound: 4}
+	sure := PotOddsBot{Equity: func(TableView) float64 { return 0.9 }}
+	hopeless := PotOddsBot{Equity: func(TableView) float64 { return 0.01 }}
+	if got := sure.CallOnce(g.view(p), 1, 5, true); got != 10 {
+		t.Errorf("expected a likely winner to call the most but got:%d", got)
+	}
+	if got := hopeless.CallOnce(g.view(p), 1, 5, true); got != 0 {
+		t.Errorf("expected a hopeless bot to quit but got:%d", got)
+	}
+	p.points = 3
+	if got := sure.CallOnce(g.view(p), 1, 5, true); got != 3 {
+		t.Errorf("expected a likely winner short of points to go all-in with 3 but got:%d", got)
+	}
+	if !sure.InOrOut(g.view(p), 5) || hopeless.InOrOut(g.view(p), 5) {
+		t.Errorf("expected to stay in only when equity beats the pot odds.")
+	}
+}
+
+func TestShortStackedBotsNeverCallOffTheirOptions(t *testing.T) {
+	players := getFourTestingPlayers()
+	for _, p := range players {
+		p.points = 6
+	}
+	d := NewDispatcher(nil)
+	d.Seat("0", NewRandomBot(1))
+	d.Seat("1", ThresholdBot{Fold: 10, Raise: 0})
+	d.Seat("2", PotOddsBot{Equity: func(TableView) float64 { return 0.9 }})
+	d.Seat("3", NewRandomBot(2))
+	log := &EventLog{}
+	s := newTestSet(10, DefaultRules())
+	s.SetSeed(5)
+	s.SetEventSink(log)
+	s.Run(players, d, &recordingDb{}, 1, 2, 0)
+	for _, e := range log.Events() {
+		if r, ok := e.(CallRejected); ok {
+			t.Errorf("expected short stacked bots to only make calls they can afford but got:%s", r.Reason)
+		}
+	}
+}
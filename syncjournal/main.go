@@ -0,0 +1,29 @@
+// Command syncjournal saves the game stats journaled while LeanCloud was unreachable to LeanCloud.
+package main
+
+import (
+	"douji"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	path := flag.String("journal", douji.DefaultJournalPath, "journal file to sync")
+	flag.Parse()
+
+	db := douji.NewLeanCloudDB().WithJournal(douji.NewJournal(*path))
+	report, err := db.Sync()
+	fmt.Printf("synced %d rows, skipped %d rows already in LeanCloud\n", report.Synced, report.Skipped)
+	for _, c := range report.Conflicts {
+		fmt.Printf("conflict: %s(%s) has %d points in LeanCloud but the journaled games started from %d, %d rows left in %s\n",
+			c.PlayerName, c.PlayerId, c.Actual, c.Expected, c.Pending, *path)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if len(report.Conflicts) > 0 {
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,106 @@
+package douji
+
+import "sort"
+
+// tied returns the players sharing the top final score, in seating order. players must be sorted by final score.
+func (g *Game) tied(players []*Player) []*Player {
+	var tied []*Player
+	for _, p := range players {
+		if p.FinalScore() == players[0].FinalScore() {
+			tied = append(tied, p)
+		}
+	}
+	g.bySeat(tied)
+	return tied
+}
+
+// bySeat sorts players in seating order.
+func (g *Game) bySeat(players []*Player) {
+	sort.SliceStable(players, func(i, j int) bool {
+		return getPlayerIndex(players[i], g.seated) < getPlayerIndex(players[j], g.seated)
+	})
+}
+
+// breakTie resolves a pot whose top final scores tie by the table's tie policy. It returns the player who shares the most of it,
+// nil when the pot carries over to the next game. A tie is split regardless of the policy once MaxBombs pots in a row were carried over.
+func (g *Game) breakTie(pot sidePot) *Player {
+	tied := g.tied(pot.players)
+	if g.rules.MaxBombs > 0 && g.bombs >= g.rules.MaxBombs {
+		g.forcedSplit = true
+		return g.share(pot.amount, tied)
+	}
+	switch g.rules.Ties {
+	case SplitTies:
+		return g.share(pot.amount, tied)
+	case TieBreakTies:
+		g.emit(TieBreakNeeded{GameId: g.id, Pot: pot.amount, PlayerIds: playerIds(tied)})
+		g.tieBreakers = g.union(g.tieBreakers, tied)
+	default:
+		g.emit(PotBombed{GameId: g.id, Pot: pot.amount, PlayerIds: playerIds(pot.players)})
+	}
+	// it's a tie so no winner yet, keep track of who the pot carried over belongs to.
+	if g.bombedShares == nil {
+		g.bombedShares = map[string]int{}
+	}
+	for id, share := range pot.shares {
+		g.bombedShares[id] += share
+	}
+	g.bombedPlayers = g.union(g.bombedPlayers, pot.players)
+	return nil
+}
+
+// union returns the players in either a or b in seating order.
+func (g *Game) union(a, b []*Player) []*Player {
+	u := append([]*Player(nil), a...)
+	for _, p := range b {
+		if !containsPlayer(u, p) {
+			u = append(u, p)
+		}
+	}
+	g.bySeat(u)
+	return u
+}
+
+// share splits amount equally between the tied players, handing the odd chips out by the table's odd chip rule.
+// It returns the first player to get an odd chip.
+func (g *Game) share(amount int, tied []*Player) *Player {
+	if g.rules.OddChip == OddChipByHighCard {
+		sort.SliceStable(tied, func(i, j int) bool {
+			return highCard(tied[i]) > highCard(tied[j])
+		})
+	}
+	g.emit(PotShared{GameId: g.id, Pot: amount, PlayerIds: playerIds(tied), Forced: g.forcedSplit})
+	each, odd := amount/len(tied), amount%len(tied)
+	for i, p := range tied {
+		share := each
+		if i < odd {
+			share++
+		}
+		if share > 0 {
+			g.pay(p, share)
+		}
+	}
+	return tied[0]
+}
+
+// highCard returns the rank of the highest card in a player's hand.
+func highCard(p *Player) int {
+	high := 0
+	for _, cards := range [2][]Card{p.privateCards, p.publicCards} {
+		for _, c := range cards {
+			if c.rank > high {
+				high = c.rank
+			}
+		}
+	}
+	return high
+}
+
+func containsPlayer(players []*Player, p *Player) bool {
+	for _, player := range players {
+		if player.id == p.id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package douji
+
+import (
+	"reflect"
+	"testing"
+)
+
+// tiedDeck deals a and b tied hands of 11 where b holds the highest card, and c a losing hand.
+func tiedDeck() *Deck {
+	return &Deck{[]Card{
+		{5, "♠"}, {4, "♣"}, {3, "♠"},
+		{6, "♠"}, {7, "♣"}, {4, "♦"},
+	}}
+}
+
+func TestSplitTies(t *testing.T) {
+	for _, tc := range []struct {
+		oddChip OddChipRule
+		a, b    int
+	}{
+		{OddChipBySeat, 13, 12},
+		{OddChipByHighCard, 12, 13},
+	} {
+		rules := DefaultRules()
+		rules.OneHiddenRounds, rules.Ties, rules.OddChip = 1, SplitTies, tc.oddChip
+		a, b := NewTestPlayer("a", "a", 10), NewTestPlayer("b", "b", 10)
+		deck := &Deck{[]Card{{5, "♠"}, {4, "♣"}, {6, "♠"}, {7, "♣"}}}
+		log := &EventLog{}
+		g := NewGame(0, []*Player{a, b}, 1, 1, 5, 1, 5, nil, rules)
+		g.SetEventSink(log)
+		winner, pot, _ := g.run(false, alwaysInMiddleGame{}, deck)
+
+		if pot != 0 || winner == nil {
+			t.Errorf("%d: expected a split pot to leave nothing over but got:%d", tc.oddChip, pot)
+		}
+		if a.points != tc.a || b.points != tc.b {
+			t.Errorf("%d: expected a:%d and b:%d after splitting a pot of 7 but got a:%d, b:%d", tc.oddChip, tc.a, tc.b, a.points, b.points)
+		}
+		for _, e := range log.Events() {
+			if _, ok := e.(PotBombed); ok {
+				t.Errorf("%d: expected a tie to split the pot instead of bombing it.", tc.oddChip)
+			}
+		}
+	}
+}
+
+func TestTieBreakTies(t *testing.T) {
+	rules := DefaultRules()
+	rules.OneHiddenRounds, rules.Ties = 1, TieBreakTies
+	a, b, c := NewTestPlayer("a", "a", 10), NewTestPlayer("b", "b", 10), NewTestPlayer("c", "c", 10)
+	log := &EventLog{}
+	g := NewGame(0, []*Player{a, b, c}, 1, 1, 0, 1, 5, nil, rules)
+	g.SetEventSink(log)
+	winner, pot, _ := g.run(false, alwaysInMiddleGame{}, tiedDeck())
+
+	if winner != nil || pot != 6 {
+		t.Errorf("expected the pot of 6 to carry over to a tie-break but got winner:%v, pot:%d", winner, pot)
+	}
+	if ids := playerIds(g.tieBreakers); !reflect.DeepEqual(ids, []string{"a", "b"}) {
+		t.Errorf("expected a and b to play the tie-break but got:%v", ids)
+	}
+	events := log.Events()
+	if tb, ok := events[len(events)-1].(TieBreakNeeded); !ok || tb.Pot != 6 {
+		t.Errorf("expected the game to end needing a tie-break but got:%+v", events[len(events)-1])
+	}
+}
+
+func TestSetRunPlaysTieBreaks(t *testing.T) {
+	rules := DefaultRules()
+	rules.Ties = TieBreakTies
+	tieBreaks := 0
+	for seed := int64(1); seed <= 20; seed++ {
+		log := &EventLog{}
+		s := newTestSet(10, rules)
+		s.SetSeed(seed)
+		s.SetEventSink(log)
+		s.Run(getFourTestingPlayers(), alwaysInMiddleGame{}, &recordingDb{}, 1, 1, 0)
+
+		var tied []string
+		for _, game := range SplitGames(log.Events()) {
+			started := game[0].(GameStarted)
+			if tied != nil {
+				tieBreaks++
+				if ids := playerIds(dtoPlayers(started.Players)); !reflect.DeepEqual(ids, tied) {
+					t.Errorf("seed %d: expected only %v to play the tie-break but got:%v", seed, tied, ids)
+				}
+				if started.Step != rules.Step || started.Pot == 0 {
+					t.Errorf("seed %d: expected a tie-break for the carried pot at the usual stakes but got:%+v", seed, started)
+				}
+			}
+			tied = nil
+			if tb, ok := game[len(game)-1].(TieBreakNeeded); ok {
+				tied = tb.PlayerIds
+			}
+		}
+	}
+	if tieBreaks == 0 {
+		t.Errorf("expected some ties in 20 sets.")
+	}
+}
+
+func dtoPlayers(pdtos []PlayerDTO) []*Player {
+	players := make([]*Player, len(pdtos))
+	for i, p := range pdtos {
+		players[i] = &Player{id: p.Id, Name: p.Name, points: p.Points}
+	}
+	return players
+}
+
+func TestRuleSetValidateTies(t *testing.T) {
+	rules := DefaultRules()
+	rules.Ties = TieBreakTies + 1
+	if rules.Validate() == nil {
+		t.Errorf("expected an unknown tie policy to be invalid.")
+	}
+	rules = DefaultRules()
+	rules.OddChip = -1
+	if rules.Validate() == nil {
+		t.Errorf("expected an unknown odd chip rule to be invalid.")
+	}
+}
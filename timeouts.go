@@ -0,0 +1,141 @@
+package douji
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ContextMiddleGame is a MiddleGame whose decisions can be abandoned once ctx is done.
+// A game always asks through these methods, with a deadline when it has decision timeouts, and takes the default action, calling 0 or going out, on any error:
+// a context.DeadlineExceeded is published as DecisionTimedOut and any other error, e.g. a disconnected player, as DecisionFailed.
+type ContextMiddleGame interface {
+	MiddleGame
+	CallOnceContext(ctx context.Context, view TableView, step, end int, lastCall bool) (int, error)
+	InOrOutContext(ctx context.Context, view TableView, callingChip int) (bool, error)
+}
+
+// DecisionTimeouts is how long a player has for each decision; zero means waiting forever.
+// Timeouts only apply to a ContextMiddleGame.
+type DecisionTimeouts struct {
+	Call    time.Duration
+	InOrOut time.Duration
+}
+
+const (
+	callDecision    = "call"
+	inOrOutDecision = "inOrOut"
+)
+
+// DecisionTimedOut is published when a player doesn't decide in time. It's followed by the default decision,
+// i.e. Called with 0 points or InOrOutDecided with out, so timeouts can be told apart from real folds.
+type DecisionTimedOut struct {
+	GameId   int
+	Round    int
+	PlayerId string
+	Decision string // "call" or "inOrOut".
+}
+
+func (DecisionTimedOut) Kind() string { return "DecisionTimedOut" }
+
+// DecisionFailed is published when asking a player for a decision fails for any reason other than a timeout.
+// Like DecisionTimedOut, it's followed by the default decision.
+type DecisionFailed struct {
+	GameId   int
+	Round    int
+	PlayerId string
+	Decision string // "call" or "inOrOut".
+	Reason   string
+}
+
+func (DecisionFailed) Kind() string { return "DecisionFailed" }
+
+// SetDecisionTimeouts sets how long each player has for a decision in the game.
+func (g *Game) SetDecisionTimeouts(t DecisionTimeouts) {
+	g.timeouts = t
+}
+
+// SetDecisionTimeouts sets how long each player has for a decision in every game of the set.
+func (s *Set) SetDecisionTimeouts(t DecisionTimeouts) {
+	s.timeouts = t
+}
+
+// askCall asks the calling player for a call, returning why the decision couldn't be made, if so.
+func (g *Game) askCall(md MiddleGame, view TableView) (int, error) {
+	lastCall := view.Round() == g.maxRound
+	cmd, ok := md.(ContextMiddleGame)
+	if !ok {
+		return md.CallOnce(view, g.step, g.end, lastCall), nil
+	}
+	ctx, cancel := decisionContext(g.timeouts.Call)
+	defer cancel()
+	return cmd.CallOnceContext(ctx, view, g.step, g.end, lastCall)
+}
+
+// askInOrOut asks a player whether to stay in, returning why the decision couldn't be made, if so.
+func (g *Game) askInOrOut(md MiddleGame, view TableView, callingPoint int) (bool, error) {
+	cmd, ok := md.(ContextMiddleGame)
+	if !ok {
+		return md.InOrOut(view, callingPoint), nil
+	}
+	ctx, cancel := decisionContext(g.timeouts.InOrOut)
+	defer cancel()
+	return cmd.InOrOutContext(ctx, view, callingPoint)
+}
+
+// decisionContext returns the context of a decision which times out after timeout, never for a zero timeout.
+func decisionContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// failed records on action why its decision couldn't be made: only a context.DeadlineExceeded is a timeout.
+func (a *Action) failed(err error) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		a.TimedOut = true
+	case err != nil:
+		a.Failed = err
+	}
+}
+
+// contextual runs a blocking MiddleGame in goroutines so its decisions can be abandoned.
+type contextual struct {
+	MiddleGame
+}
+
+// Contextual adapts a blocking MiddleGame to a ContextMiddleGame. An abandoned decision keeps running in the background
+// and its answer is dropped, so it's only suitable for decision makers which don't share input between decisions.
+// Since a TableView is an immutable snapshot, the abandoned decision never sees the game move on.
+func Contextual(md MiddleGame) ContextMiddleGame {
+	return contextual{md}
+}
+
+// CheckSeats forwards the check of a wrapped SeatChecker.
+func (c contextual) CheckSeats(players []*Player) error {
+	return checkSeats(c.MiddleGame, players)
+}
+
+func (c contextual) CallOnceContext(ctx context.Context, view TableView, step, end int, lastCall bool) (int, error) {
+	ch := make(chan int, 1)
+	go func() { ch <- c.CallOnce(view, step, end, lastCall) }()
+	select {
+	case points := <-ch:
+		return points, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func (c contextual) InOrOutContext(ctx context.Context, view TableView, callingChip int) (bool, error) {
+	ch := make(chan bool, 1)
+	go func() { ch <- c.InOrOut(view, callingChip) }()
+	select {
+	case in := <-ch:
+		return in, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
@@ -0,0 +1,170 @@
+package douji
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// a middle game where one player never decides; everyone else calls the step and stays in.
+type walkedAwayMiddleGame struct {
+	alwaysInMiddleGame
+	awayId string
+}
+
+func (md walkedAwayMiddleGame) CallOnceContext(ctx context.Context, view TableView, step, end int, lastCall bool) (int, error) {
+	if view.Self().Id == md.awayId {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	return step, nil
+}
+
+func (md walkedAwayMiddleGame) InOrOutContext(ctx context.Context, view TableView, callingChip int) (bool, error) {
+	if view.Self().Id == md.awayId {
+		<-ctx.Done()
+		return false, ctx.Err()
+	}
+	return true, nil
+}
+
+func TestDecisionTimeouts(t *testing.T) {
+	players := getFourTestingPlayers()
+	game := NewGame(0, players, 1, 2, 0, 1, 5, nil, DefaultRules())
+	game.seed = 3
+	game.SetDecisionTimeouts(DecisionTimeouts{Call: 10 * time.Millisecond, InOrOut: 10 * time.Millisecond})
+	log := &EventLog{}
+	game.SetEventSink(log)
+	game.run(false, walkedAwayMiddleGame{awayId: "0"}, NewSeededDeck(game.seed))
+
+	events := log.Events()
+	timeouts := 0
+	for i, e := range events {
+		to, ok := e.(DecisionTimedOut)
+		if !ok {
+			continue
+		}
+		timeouts++
+		if to.PlayerId != "0" {
+			t.Errorf("expected only Liu to time out but got:%s", to.PlayerId)
+		}
+		switch next := events[i+1].(type) {
+		case Called:
+			if next.Points != 0 {
+				t.Errorf("expected a timed out call to default to 0 but got:%d", next.Points)
+			}
+		case InOrOutDecided:
+			if next.In {
+				t.Errorf("expected a timed out in or out to default to out.")
+			}
+		default:
+			t.Errorf("expected the default decision after a timeout but got:%s", next.Kind())
+		}
+	}
+	if timeouts != 1 {
+		t.Errorf("expected Liu to time out once before leaving the game but got:%d", timeouts)
+	}
+	if players[0].points != 99 {
+		t.Errorf("expected Liu to lose only the base after timing out but got:%d", players[0].points)
+	}
+	if _, err := Replay(events); err != nil {
+		t.Errorf("expected a game with timeouts to replay but got:%v", err)
+	}
+}
+
+// a middle game where one player has gone, so asking them fails straight away.
+type goneMiddleGame struct {
+	alwaysInMiddleGame
+	goneId string
+}
+
+var errGone = errors.New("player gone")
+
+func (md goneMiddleGame) CallOnceContext(ctx context.Context, view TableView, step, end int, lastCall bool) (int, error) {
+	if view.Self().Id == md.goneId {
+		return 0, errGone
+	}
+	return step, nil
+}
+
+func (md goneMiddleGame) InOrOutContext(ctx context.Context, view TableView, callingChip int) (bool, error) {
+	if view.Self().Id == md.goneId {
+		return false, errGone
+	}
+	return true, nil
+}
+
+func TestDecisionFailedIsNotATimeout(t *testing.T) {
+	players := getFourTestingPlayers()
+	game := NewGame(0, players, 1, 2, 0, 1, 5, nil, DefaultRules())
+	game.seed = 3
+	game.SetDecisionTimeouts(DecisionTimeouts{Call: time.Hour, InOrOut: time.Hour})
+	log := &EventLog{}
+	game.SetEventSink(log)
+	game.run(false, goneMiddleGame{goneId: "0"}, NewSeededDeck(game.seed))
+
+	events := log.Events()
+	failures := 0
+	for i, e := range events {
+		switch ev := e.(type) {
+		case DecisionTimedOut:
+			t.Errorf("expected a failed decision not to be reported as a timeout but got:%+v", ev)
+		case DecisionFailed:
+			failures++
+			if ev.PlayerId != "0" || ev.Reason != errGone.Error() {
+				t.Errorf("expected Liu's decision to fail with %q but got:%+v", errGone, ev)
+			}
+			if c, ok := events[i+1].(Called); ok && c.Points != 0 {
+				t.Errorf("expected a failed call to default to 0 but got:%d", c.Points)
+			}
+			if d, ok := events[i+1].(InOrOutDecided); ok && d.In {
+				t.Errorf("expected a failed in or out to default to out.")
+			}
+		}
+	}
+	if failures != 1 {
+		t.Errorf("expected Liu's decision to fail once before leaving the game but got:%d", failures)
+	}
+	if _, err := Replay(events); err != nil {
+		t.Errorf("expected a game with failed decisions to replay but got:%v", err)
+	}
+}
+
+func TestDecisionTimeoutsIgnorePlainMiddleGame(t *testing.T) {
+	players := getFourTestingPlayers()
+	game := NewGame(0, players, 1, 1, 0, 1, 5, nil, DefaultRules())
+	game.SetDecisionTimeouts(DecisionTimeouts{Call: time.Nanosecond, InOrOut: time.Nanosecond})
+	log := &EventLog{}
+	game.SetEventSink(log)
+	game.run(false, alwaysInMiddleGame{}, NewSeededDeck(1))
+	for _, e := range log.Events() {
+		if _, ok := e.(DecisionTimedOut); ok {
+			t.Fatalf("expected no timeouts for a MiddleGame without context support.")
+		}
+	}
+}
+
+type stuckMiddleGame struct{}
+
+func (stuckMiddleGame) CallOnce(view TableView, step, end int, lastCall bool) int {
+	time.Sleep(time.Second)
+	return step
+}
+
+func (stuckMiddleGame) InOrOut(view TableView, callingChip int) bool {
+	time.Sleep(time.Second)
+	return true
+}
+
+func TestContextual(t *testing.T) {
+	md := Contextual(stuckMiddleGame{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := md.CallOnceContext(ctx, TableView{}, 1, 5, false); err != context.DeadlineExceeded {
+		t.Errorf("expected a stuck call to be abandoned but got:%v", err)
+	}
+	if in, err := Contextual(alwaysInMiddleGame{}).InOrOutContext(context.Background(), TableView{}, 1); err != nil || !in {
+		t.Errorf("expected the wrapped decision but got:%v, %v", in, err)
+	}
+}
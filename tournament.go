@@ -0,0 +1,222 @@
+package douji
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// StrategyFactory creates a fresh strategy for each set so bots never share state between sets running in parallel.
+type StrategyFactory func(seed int64) Strategy
+
+// TournamentConfig is how a tournament between bots is played. Each set seats one player per entry of Lineup in a rotating order.
+type TournamentConfig struct {
+	Sets        int
+	GamesPerSet int
+	Workers     int // sets played in parallel, at least 1.
+	Base        int
+	HiddenCount int
+	Points      int // starting points of every player in every set.
+	Seed        int64
+	Lineup      []StrategyFactory
+	Rules       RuleSet // the zero value plays by the default rules.
+	Db          Db      // receives every game's stats, nil discards them.
+}
+
+// StrategyReport sums up how a strategy played in a tournament.
+type StrategyReport struct {
+	Name       string
+	Games      int // games played by players of the strategy.
+	Wins       int
+	WinRate    float64
+	PointDelta float64 // average points won or lost per set.
+	sets       int
+	delta      int
+}
+
+// TournamentReport sums up a tournament.
+type TournamentReport struct {
+	Sets          int
+	Games         int
+	Strategies    []StrategyReport // sorted by win rate, best first.
+	BombedPots    int
+	BombedPotRate float64 // bombed pots per game.
+	Showdowns     int     // games decided in the final round with more than one player.
+	FiveKinds     int     // five a kind hands (scoring 300) at showdowns.
+	FourKinds     int     // four a kind hands at showdowns, excluding five a kinds.
+	AvgRounds     float64 // rounds played per game.
+	rounds        int
+}
+
+// discardDb is a Db dropping every game's stats.
+type discardDb struct{}
+
+func (discardDb) SaveGameStats(setId string, gameId int, seed int64, pot PotStats, pnp []PlayerDTO) error {
+	return nil
+}
+
+func (discardDb) LoadPlayerStatsByName(name string) (*Player, error) { return nil, ErrPlayerNotFound }
+
+func (discardDb) CreatePlayer(name, password string, points int) (string, error) { return "", nil }
+
+// RunTournament plays sets between bots with Set.Run and reports how every strategy did.
+// Sets cfg.Db failed to save still count in the report, which is returned with the first such error.
+func RunTournament(cfg TournamentConfig) (TournamentReport, error) {
+	if len(cfg.Lineup) < 2 {
+		return TournamentReport{}, fmt.Errorf("a tournament needs at least two players but got:%d", len(cfg.Lineup))
+	}
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.Db == nil {
+		cfg.Db = discardDb{}
+	}
+	cfg.Rules = cfg.Rules.orDefault()
+	if err := cfg.Rules.Validate(); err != nil {
+		return TournamentReport{}, err
+	}
+	seeds := rand.New(rand.NewSource(cfg.Seed))
+	setSeeds := make([]int64, cfg.Sets)
+	for i := range setSeeds {
+		setSeeds[i] = seeds.Int63()
+	}
+
+	var mu sync.Mutex
+	var saveErr error
+	report := TournamentReport{}
+	byName := map[string]*StrategyReport{}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				names, players, events, err := playTournamentSet(cfg, i, setSeeds[i])
+				mu.Lock()
+				if err != nil && saveErr == nil {
+					saveErr = err
+				}
+				report.addSet(byName, names, players, cfg, events)
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < cfg.Sets; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	report.Sets = cfg.Sets
+	if report.Games > 0 {
+		report.BombedPotRate = float64(report.BombedPots) / float64(report.Games)
+		report.AvgRounds = float64(report.rounds) / float64(report.Games)
+	}
+	for _, sr := range byName {
+		if sr.Games > 0 {
+			sr.WinRate = float64(sr.Wins) / float64(sr.Games)
+		}
+		if sr.sets > 0 {
+			sr.PointDelta = float64(sr.delta) / float64(sr.sets)
+		}
+		report.Strategies = append(report.Strategies, *sr)
+	}
+	sort.SliceStable(report.Strategies, func(i, j int) bool {
+		a, b := report.Strategies[i], report.Strategies[j]
+		if a.WinRate != b.WinRate {
+			return a.WinRate > b.WinRate
+		}
+		return a.Name < b.Name // strategies come from a map, so ties are ordered by name.
+	})
+	return report, saveErr
+}
+
+// playTournamentSet plays one set and returns the strategy name of every player id, the players, the set's events
+// and any games of the set cfg.Db failed to save.
+func playTournamentSet(cfg TournamentConfig, i int, seed int64) (map[string]string, []*Player, []Event, error) {
+	r := rand.New(rand.NewSource(seed))
+	n := len(cfg.Lineup)
+	players := make([]*Player, n)
+	names := make(map[string]string, n)
+	d := NewDispatcher(nil)
+	for seat := 0; seat < n; seat++ {
+		entry := (seat + i) % n // rotate seats so no strategy always sits first.
+		strategy := cfg.Lineup[entry](r.Int63())
+		id := fmt.Sprint(entry)
+		players[seat] = NewTestPlayer(fmt.Sprintf("%s-%d", strategy.Name(), entry), id, cfg.Points)
+		names[id] = strategy.Name()
+		d.Seat(id, strategy)
+	}
+	log := &EventLog{}
+	s, err := NewSet(cfg.GamesPerSet, false, cfg.Rules)
+	if err != nil {
+		return names, players, nil, err
+	}
+	s.SetSeed(r.Int63())
+	s.SetEventSink(log)
+	err = s.Run(players, d, cfg.Db, cfg.Base, cfg.HiddenCount, 0)
+	return names, players, log.Events(), err
+}
+
+func (report *TournamentReport) addSet(byName map[string]*StrategyReport, names map[string]string, players []*Player, cfg TournamentConfig, events []Event) {
+	strategyOf := func(id string) *StrategyReport {
+		name := names[id]
+		if byName[name] == nil {
+			byName[name] = &StrategyReport{Name: name}
+		}
+		return byName[name]
+	}
+	for _, p := range players {
+		sr := strategyOf(p.id)
+		sr.sets++
+		sr.delta += p.points - cfg.Points
+	}
+	for _, game := range SplitGames(events) {
+		report.Games++
+		started, _ := game[0].(GameStarted)
+		for _, p := range started.Players { // players sitting out a carried pot didn't play the game.
+			strategyOf(p.Id).Games++
+		}
+		won := map[string]bool{} // side pots and splits pay a player more than once a game.
+		hands := map[string]*Player{}
+		var last RoundEnded
+		for _, e := range game {
+			switch ev := e.(type) {
+			case CardDealt:
+				if hands[ev.PlayerId] == nil {
+					hands[ev.PlayerId] = &Player{id: ev.PlayerId, rules: &cfg.Rules}
+				}
+				if ev.Hidden {
+					hands[ev.PlayerId].ReceivePrivateCard(ev.Card)
+				} else {
+					hands[ev.PlayerId].ReceivePublicCard(ev.Card)
+				}
+			case RoundEnded:
+				last = ev
+			case PotBombed:
+				report.BombedPots++
+			case WinnerPaid:
+				if !won[ev.PlayerId] {
+					won[ev.PlayerId] = true
+					strategyOf(ev.PlayerId).Wins++
+				}
+			}
+		}
+		report.rounds += last.Round
+		if len(last.PlayerIds) < 2 {
+			continue // the game was over before the final round.
+		}
+		report.Showdowns++
+		for _, id := range last.PlayerIds {
+			h := hands[id]
+			switch score := h.FinalScore(); {
+			case score == cfg.Rules.FiveKindScore:
+				report.FiveKinds++
+			case h.IsFourKind():
+				report.FourKinds++
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Command tournament plays thousands of sets between bots without any input and prints how every strategy did.
+package main
+
+import (
+	"douji"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var bots = map[string]douji.StrategyFactory{
+	"random": func(seed int64) douji.Strategy { return douji.NewRandomBot(seed) },
+	"threshold": func(seed int64) douji.Strategy {
+		return douji.ThresholdBot{Fold: 10, Raise: 20}
+	},
+	"potOdds": func(seed int64) douji.Strategy { return douji.PotOddsBot{} },
+	"montecarlo": func(seed int64) douji.Strategy {
+		return montecarlo{douji.PotOddsBot{Equity: douji.MonteCarloEquity(200, seed)}}
+	},
+}
+
+// montecarlo is a pot odds bot using simulated equity, named apart from the quick estimate one.
+type montecarlo struct {
+	douji.PotOddsBot
+}
+
+func (montecarlo) Name() string { return "montecarlo" }
+
+func main() {
+	sets := flag.Int("sets", 1000, "number of sets to play")
+	games := flag.Int("games", 10, "games per set")
+	workers := flag.Int("workers", runtime.NumCPU(), "sets played in parallel")
+	base := flag.Int("base", 1, "base point of every game")
+	hidden := flag.Int("hidden", 1, "hidden cards per player, 1 or 2")
+	points := flag.Int("points", 1000, "starting points of every player")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "seed of the whole tournament")
+	lineup := flag.String("lineup", "random,threshold,potOdds,montecarlo", "comma separated bots seated in every set: random, threshold, potOdds or montecarlo")
+	dbMode := flag.String("db", "none", "where every game's stats are saved: none, csv for csv files in the current directory, or leancloud")
+	flag.Parse()
+
+	cfg := douji.TournamentConfig{
+		Sets:        *sets,
+		GamesPerSet: *games,
+		Workers:     *workers,
+		Base:        *base,
+		HiddenCount: *hidden,
+		Points:      *points,
+		Seed:        *seed,
+	}
+	switch *dbMode {
+	case "none":
+	case "csv":
+		cfg.Db = douji.NewCSV()
+	case "leancloud":
+		cfg.Db = douji.NewLeanCloudDB().WithJournal(douji.NewJournal(douji.DefaultJournalPath))
+	default:
+		fmt.Fprintf(os.Stderr, "unknown db:%s\n", *dbMode)
+		os.Exit(2)
+	}
+	for _, name := range strings.Split(*lineup, ",") {
+		bot, ok := bots[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown bot:%s\n", name)
+			os.Exit(2)
+		}
+		cfg.Lineup = append(cfg.Lineup, bot)
+	}
+
+	start := time.Now()
+	report, err := douji.RunTournament(cfg)
+	if err != nil && report.Sets == 0 {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d sets, %d games in %v (seed %d)\n", report.Sets, report.Games, time.Since(start).Round(time.Millisecond), *seed)
+	fmt.Printf("bombed pots: %d (%.2f%% of games)\n", report.BombedPots, report.BombedPotRate*100)
+	fmt.Printf("showdowns: %d, five a kinds: %d, four a kinds: %d\n", report.Showdowns, report.FiveKinds, report.FourKinds)
+	fmt.Printf("average rounds per game: %.2f\n\n", report.AvgRounds)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "strategy\tgames\twins\twin rate\tpoints per set")
+	for _, sr := range report.Strategies {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.2f%%\t%+.2f\n", sr.Name, sr.Games, sr.Wins, sr.WinRate*100, sr.PointDelta)
+	}
+	w.Flush()
+	if err != nil {
+		// the sets still count, but some of their stats weren't saved.
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,102 @@
+package douji
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRunTournament(t *testing.T) {
+	cfg := TournamentConfig{
+		Sets:        6,
+		GamesPerSet: 3,
+		Workers:     3,
+		Base:        1,
+		HiddenCount: 2,
+		Points:      1000,
+		Seed:        7,
+		Lineup: []StrategyFactory{
+			func(seed int64) Strategy { return NewRandomBot(seed) },
+			func(seed int64) Strategy { return ThresholdBot{Fold: 10, Raise: 30} },
+			func(seed int64) Strategy { return PotOddsBot{} },
+		},
+	}
+	report, err := RunTournament(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error:%v", err)
+	}
+	if report.Sets != cfg.Sets || report.Games < cfg.Sets*cfg.GamesPerSet {
+		t.Errorf("expected %d sets with at least %d games but got %d sets with %d games.", cfg.Sets, cfg.Sets*cfg.GamesPerSet, report.Sets, report.Games)
+	}
+	if len(report.Strategies) != len(cfg.Lineup) {
+		t.Fatalf("expected %d strategies but got:%d", len(cfg.Lineup), len(report.Strategies))
+	}
+	wins, delta := 0, 0.0
+	for _, sr := range report.Strategies {
+		if sr.Games != report.Games {
+			t.Errorf("expected %s to play all %d games but got:%d", sr.Name, report.Games, sr.Games)
+		}
+		wins += sr.Wins
+		delta += sr.PointDelta
+	}
+	if wins+report.BombedPots != report.Games {
+		t.Errorf("expected every game to be won or bombed but got %d wins and %d bombed pots in %d games.", wins, report.BombedPots, report.Games)
+	}
+	if delta > 1e-9 { // a pot bombed in the last game of a set is never paid.
+		t.Errorf("expected no points to be created but the strategies won %v points per set.", delta)
+	}
+
+	cfg.Workers = 1
+	again, _ := RunTournament(cfg)
+	if !reflect.DeepEqual(report, again) {
+		t.Errorf("expected the same seed to give the same report regardless of workers.")
+	}
+}
+
+func TestRunTournamentNeedsTwoPlayers(t *testing.T) {
+	if _, err := RunTournament(TournamentConfig{Sets: 1, Lineup: []StrategyFactory{func(int64) Strategy { return PotOddsBot{} }}}); err == nil {
+		t.Errorf("expected an error for a single player tournament.")
+	}
+}
+
+func TestRunTournamentOrdersTiesByName(t *testing.T) {
+	cfg := TournamentConfig{Sets: 2, GamesPerSet: 1, Base: 1, HiddenCount: 1, Points: 100, Seed: 1, Lineup: []StrategyFactory{
+		func(int64) Strategy { return fixedStrategy{name: "c"} },
+		func(int64) Strategy { return fixedStrategy{name: "a"} },
+		func(int64) Strategy { return fixedStrategy{name: "b"} },
+	}}
+	for i := 0; i < 5; i++ {
+		report, err := RunTournament(cfg)
+		if err != nil {
+			t.Fatalf("unexpected error:%v", err)
+		}
+		var names []string
+		for _, sr := range report.Strategies {
+			names = append(names, sr.Name)
+		}
+		if !reflect.DeepEqual(names, []string{"b", "a", "c"}) {
+			t.Fatalf("expected the winner first and the tied strategies by name but got:%v", names)
+		}
+	}
+}
+
+func TestTournamentCountsGamesAndWinsOncePerPlayer(t *testing.T) {
+	players := []*Player{NewTestPlayer("a", "0", 100), NewTestPlayer("b", "1", 100), NewTestPlayer("c", "2", 100)}
+	names := map[string]string{"0": "a", "1": "b", "2": "c"}
+	events := []Event{
+		GameStarted{GameId: 0, Players: convertToPlayerDTO(players)},
+		WinnerPaid{GameId: 0, PlayerId: "0", Pot: 0, Points: 6},
+		PotBombed{GameId: 0},
+		GameStarted{GameId: 1, Players: convertToPlayerDTO(players[:2])}, // c has no share of the carried pot.
+		WinnerPaid{GameId: 1, PlayerId: "0", Pot: 0, Points: 4},
+		WinnerPaid{GameId: 1, PlayerId: "0", Pot: 1, Points: 2}, // a side pot.
+	}
+	var report TournamentReport
+	byName := map[string]*StrategyReport{}
+	report.addSet(byName, names, players, TournamentConfig{Points: 100, Rules: DefaultRules()}, events)
+	if a := byName["a"]; a.Games != 2 || a.Wins != 2 {
+		t.Errorf("expected a to win both games once each but got %d wins in %d games.", a.Wins, a.Games)
+	}
+	if c := byName["c"]; c.Games != 1 {
+		t.Errorf("expected c to play only the game they sat in but got:%d", c.Games)
+	}
+}
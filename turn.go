@@ -0,0 +1,329 @@
+package douji
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TurnKind is the kind of decision a game waits for.
+type TurnKind int
+
+const (
+	// NoTurn means the game waits for nothing: it hasn't started or it's over.
+	NoTurn TurnKind = iota
+	// CallTurn waits for the calling player to call one of the options, 0 meaning quitting the game.
+	CallTurn
+	// InOrOutTurn waits for a player to stay in on the calling points or go out.
+	InOrOutTurn
+)
+
+// Turn is the decision a game waits for: whose turn it is and what they can choose from.
+type Turn struct {
+	Kind     TurnKind
+	Round    int
+	PlayerId string
+	Options  []int     // the calls to choose from in a CallTurn.
+	Points   int       // the calling points to stay in on in an InOrOutTurn.
+	View     TableView // the game as seen by the player.
+}
+
+// Action is a player's decision applied to a game.
+type Action struct {
+	PlayerId string
+	Points   int   // the call in a CallTurn.
+	In       bool  // whether to stay in in an InOrOutTurn.
+	TimedOut bool  // the player didn't decide in time and takes the default action, calling 0 or going out.
+	Failed   error // the player couldn't be asked for the decision, e.g. they disconnected, and takes the default action.
+}
+
+var (
+	// ErrGameOver is returned when an action is applied to a game which doesn't wait for one.
+	ErrGameOver = errors.New("game isn't waiting for any action")
+	// ErrNotYourTurn is returned for an action of a player other than the one whose turn it is.
+	ErrNotYourTurn = errors.New("not your turn")
+	// ErrInvalidCall is returned for a call which is neither on the calling ladder of the round nor going all-in.
+	ErrInvalidCall = errors.New("invalid call")
+	// ErrInsufficientPoints is returned for a call on the ladder of more points than the calling player has.
+	ErrInsufficientPoints = errors.New("insufficient points")
+)
+
+// maxCallAttempts is how many invalid calls a MiddleGame can make in a turn before the player quits the game.
+const maxCallAttempts = 3
+
+// Start deals the game and moves it on to the first decision, which NextAction returns.
+func (g *Game) Start(dealer CardDealer) error {
+	if err := g.start(dealer); err != nil {
+		return err
+	}
+	g.dealer = dealer
+	if g.print {
+		fmt.Println("Game started!")
+		g.printCurrentStatus(0)
+	}
+	g.startRound()
+	return nil
+}
+
+// NextAction returns the decision the game waits for, NoTurn once it's over.
+func (g *Game) NextAction() Turn {
+	switch g.turn {
+	case CallTurn:
+		return Turn{
+			Kind:     CallTurn,
+			Round:    g.round,
+			PlayerId: g.caller.id,
+			Options:  affordable(g.callLadder(), g.caller.points),
+			View:     g.view(g.caller),
+		}
+	case InOrOutTurn:
+		p := g.asking[0]
+		return Turn{Kind: InOrOutTurn, Round: g.round, PlayerId: p.id, Points: g.callingPoint, View: g.view(p)}
+	}
+	return Turn{}
+}
+
+// Apply applies the decision of the player whose turn it is and moves the game on to the next decision, which it returns.
+// An action out of turn or an invalid call is rejected with a typed error, leaving the game waiting for the same decision.
+func (g *Game) Apply(a Action) (Turn, error) {
+	p := g.waitingFor()
+	switch {
+	case p == nil:
+		return g.NextAction(), ErrGameOver
+	case a.PlayerId != p.id:
+		return g.NextAction(), fmt.Errorf("%w: it's %s's turn but %s acted", ErrNotYourTurn, p.id, a.PlayerId)
+	}
+	if g.turn == CallTurn {
+		if g.defaulted(a, callDecision) {
+			a.Points = 0
+		} else if err := g.validateCall(p, a.Points); err != nil {
+			return g.NextAction(), err
+		}
+		g.applyCall(a.Points)
+	} else {
+		if g.defaulted(a, inOrOutDecision) {
+			a.In = false
+		}
+		g.applyInOrOut(a.In)
+	}
+	return g.NextAction(), nil
+}
+
+// defaulted publishes why the player takes the default action of decision, reporting whether they do.
+func (g *Game) defaulted(a Action, decision string) bool {
+	switch {
+	case a.TimedOut:
+		g.emit(DecisionTimedOut{GameId: g.id, Round: g.round, PlayerId: a.PlayerId, Decision: decision})
+	case a.Failed != nil:
+		g.emit(DecisionFailed{GameId: g.id, Round: g.round, PlayerId: a.PlayerId, Decision: decision, Reason: a.Failed.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// callLadder returns the calls of the current round.
+func (g *Game) callLadder() []int {
+	return g.rules.orDefault().callLadder(g.step, g.end, g.round == g.maxRound)
+}
+
+// validateCall checks a call is one the calling player can make: a call on the calling ladder they have the points for,
+// or going all-in.
+func (g *Game) validateCall(p *Player, points int) error {
+	ladder := g.callLadder()
+	switch {
+	case containsInt(affordable(ladder, p.points), points):
+		return nil
+	case containsInt(ladder, points):
+		return fmt.Errorf("%w: %s called %d with only %d points", ErrInsufficientPoints, p.id, points, p.points)
+	}
+	return fmt.Errorf("%w: %s called %d but can only call one of %v", ErrInvalidCall, p.id, points, ladder)
+}
+
+// affordable returns the calls of ladder a player with points can make: quitting, the calls they have the points for and,
+// when they can't cover every call, going all-in with every point they have left.
+func affordable(ladder []int, points int) []int {
+	var calls []int
+	for _, c := range ladder {
+		if c == 0 || c <= points {
+			calls = append(calls, c)
+		} else if points > 0 && !containsInt(calls, points) {
+			calls = append(calls, points) // the ladder is in order, so going all-in comes right after the calls covered.
+		}
+	}
+	return calls
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// waitingFor returns the player whose decision the game waits for, nil when it waits for none.
+func (g *Game) waitingFor() *Player {
+	switch g.turn {
+	case CallTurn:
+		return g.caller
+	case InOrOutTurn:
+		return g.asking[0]
+	}
+	return nil
+}
+
+// Result returns the winner of the game with its finished pot, like run does. Unless it's bombed pot, the ending pot is 0.
+func (g *Game) Result() (*Player, int) {
+	return g.winner, g.endPot
+}
+
+// startRound moves on to the next round, waiting for its calling player's call.
+// With nobody left to call against, all-in players only wait for the cards and the round ends straight away.
+func (g *Game) startRound() {
+	g.round++
+	if g.round > g.maxRound {
+		g.finish()
+		return
+	}
+	if cp := g.getCallingPlayer(g.round == 1); cp != nil && g.callers() > 1 {
+		g.turn, g.caller = CallTurn, cp
+		return
+	}
+	g.endRound()
+}
+
+func (g *Game) applyCall(callingPoint int) {
+	cp := g.caller
+	g.called(cp, callingPoint)
+	if callingPoint == 0 {
+		g.emit(PlayerFolded{GameId: g.id, Round: g.round, PlayerId: cp.id})
+		idx := getPlayerIndex(cp, g.players)
+		g.players = g.getAskingPlayers(idx)
+		if len(g.players) == 1 || g.callers() < 2 {
+			g.endRound() // one player left, game over, or nobody left to call against.
+			return
+		}
+		g.caller = g.getCallingPlayer(g.round == 1)
+		return
+	}
+	g.take(cp, callingPoint) // update calling player's chips and the pot.
+	g.callingPoint = callingPoint
+	g.inPlayers = []*Player{cp} // calling player always remains in the game.
+	g.asking = g.getAskingPlayers(getPlayerIndex(cp, g.players))
+	g.nextAsking()
+}
+
+// nextAsking waits for the next asking player to decide in or out, ending the round once everyone has.
+func (g *Game) nextAsking() {
+	for len(g.asking) > 0 && !g.canCall(g.asking[0]) {
+		g.inPlayers = append(g.inPlayers, g.asking[0]) // an all-in player is never asked and stays in.
+		g.asking = g.asking[1:]
+	}
+	if len(g.asking) > 0 {
+		g.turn = InOrOutTurn
+		return
+	}
+	g.players = g.inPlayers
+	g.endRound()
+}
+
+func (g *Game) applyInOrOut(in bool) {
+	player := g.asking[0]
+	g.asking = g.asking[1:]
+	g.emit(InOrOutDecided{GameId: g.id, Round: g.round, PlayerId: player.id, Points: g.callingPoint, In: in})
+	if in {
+		g.take(player, g.callingPoint)
+		g.inPlayers = append(g.inPlayers, player)
+	} else {
+		g.emit(PlayerFolded{GameId: g.id, Round: g.round, PlayerId: player.id})
+	}
+	g.nextAsking()
+}
+
+// endRound ends the current round, dealing the next one unless the game is over.
+func (g *Game) endRound() {
+	g.turn = NoTurn
+	g.emit(RoundEnded{GameId: g.id, Round: g.round, Pot: g.pot, PlayerIds: playerIds(g.players)})
+	if len(g.players) == 1 {
+		g.finish() // game over as only one player is left.
+		return
+	}
+	if g.round < g.maxRound { // deal a round before the last round.
+		g.dealARound(g.dealer, g.players, g.round)
+	}
+	if g.print {
+		g.printCurrentStatus(g.round)
+	}
+	g.startRound()
+}
+
+// finish pays the last player standing or, with more than 1 player in the final round, settles every side pot.
+func (g *Game) finish() {
+	g.turn = NoTurn
+	if len(g.players) == 1 {
+		g.pay(g.players[0], g.pot) // the last player standing takes every side pot.
+		g.status = over
+		g.winner, g.endPot = g.players[0], 0
+		return
+	}
+	g.winner, g.endPot = g.showdown()
+	g.status = over
+	if g.endPot > 0 {
+		g.status = bombing
+	}
+}
+
+// run a game and return its winner player with the finished game pot. Unless it's bombed pot, the ending pot is 0.
+// It drives the game by asking md for every decision the game waits for, failing when the game can't start, e.g. with ErrNotEnoughCards
+// or a SeatChecker's error such as a Dispatcher's ErrNoStrategy.
+func (g *Game) run(print bool, md MiddleGame, cardDealer CardDealer) (*Player, int, error) {
+	g.print = print
+	if err := checkSeats(md, g.players); err != nil {
+		return nil, 0, fmt.Errorf("failed to start the game: %w", err)
+	}
+	if err := g.Start(cardDealer); err != nil {
+		return nil, 0, fmt.Errorf("failed to start the game: %w", err)
+	}
+	for turn := g.NextAction(); turn.Kind != NoTurn; {
+		turn = g.decide(md, turn)
+	}
+	winner, pot := g.Result()
+	return winner, pot, nil
+}
+
+// decide asks md for the decision of a turn and applies it, returning the next turn. An invalid call is rejected and md asked again,
+// up to maxCallAttempts times before the player quits the game.
+func (g *Game) decide(md MiddleGame, turn Turn) Turn {
+	for attempt := 1; ; attempt++ {
+		action := g.ask(md, turn)
+		next, err := g.Apply(action)
+		if err == nil {
+			return next
+		}
+		if !errors.Is(err, ErrInvalidCall) && !errors.Is(err, ErrInsufficientPoints) {
+			panic(err)
+		}
+		g.emit(CallRejected{GameId: g.id, Round: turn.Round, PlayerId: turn.PlayerId, Points: action.Points, Reason: err.Error()})
+		if attempt == maxCallAttempts {
+			next, err := g.Apply(Action{PlayerId: turn.PlayerId}) // quit the game.
+			if err != nil {
+				panic(err)
+			}
+			return next
+		}
+	}
+}
+
+// ask asks md for the decision of a turn, which falls back to the default action when it times out or fails.
+func (g *Game) ask(md MiddleGame, turn Turn) Action {
+	action := Action{PlayerId: turn.PlayerId}
+	var err error
+	if turn.Kind == CallTurn {
+		action.Points, err = g.askCall(md, turn.View)
+	} else {
+		action.In, err = g.askInOrOut(md, turn.View, turn.Points)
+	}
+	action.failed(err)
+	return action
+}
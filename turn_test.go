@@ -0,0 +1,198 @@
+package douji
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStepApiPlaysLikeRun(t *testing.T) {
+	run := &EventLog{}
+	g := NewGame(0, getFourTestingPlayers(), 1, 2, 0, 1, 5, nil, DefaultRules())
+	g.SetEventSink(run)
+	g.run(false, alwaysInMiddleGame{}, NewSeededDeck(5))
+
+	stepped := &EventLog{}
+	players := getFourTestingPlayers()
+	g = NewGame(0, players, 1, 2, 0, 1, 5, nil, DefaultRules())
+	g.SetEventSink(stepped)
+	if turn := g.NextAction(); turn.Kind != NoTurn {
+		t.Errorf("expected a game to wait for nothing before it starts but got:%+v", turn)
+	}
+	if err := g.Start(NewSeededDeck(5)); err != nil {
+		t.Fatal(err)
+	}
+	turns := 0
+	for turn := g.NextAction(); turn.Kind != NoTurn; turns++ {
+		if turn.View.Self().Id != turn.PlayerId || turn.Round != g.round {
+			t.Errorf("expected the turn to come with the view of its player but got:%+v", turn)
+		}
+		action := Action{PlayerId: turn.PlayerId, In: true}
+		if turn.Kind == CallTurn {
+			action.Points = turn.Options[1]
+		}
+		next, err := g.Apply(action)
+		if err != nil {
+			t.Fatal(err)
+		}
+		turn = next
+	}
+	if turns == 0 || !reflect.DeepEqual(run.Events(), stepped.Events()) {
+		t.Errorf("expected stepping through %d turns to publish the same events as running the game.", turns)
+	}
+	if winner, pot := g.Result(); winner == nil && pot == 0 {
+		t.Errorf("expected the stepped game to have a result.")
+	}
+	if _, err := g.Apply(Action{PlayerId: players[0].id}); !errors.Is(err, ErrGameOver) {
+		t.Errorf("expected an action after the game is over to fail but got:%v", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	players := getFourTestingPlayers()
+	log := &EventLog{}
+	g := NewGame(0, players, 1, 1, 0, 1, 5, nil, DefaultRules())
+	g.SetEventSink(log)
+	if err := g.Start(NewSeededDeck(5)); err != nil {
+		t.Fatal(err)
+	}
+	turn := g.NextAction()
+	if turn.Kind != CallTurn || !reflect.DeepEqual(turn.Options, []int{0, 1, 2, 3, 4, 5}) {
+		t.Fatalf("expected the first turn to be a call from the ladder but got:%+v", turn)
+	}
+	other := players[0].id
+	if other == turn.PlayerId {
+		other = players[1].id
+	}
+	if next, err := g.Apply(Action{PlayerId: other, Points: 1}); err == nil || next.PlayerId != turn.PlayerId {
+		t.Errorf("expected an action out of turn to fail and keep waiting for %s but got:%+v, %v", turn.PlayerId, next, err)
+	}
+
+	next, err := g.Apply(Action{PlayerId: turn.PlayerId, Points: 3, TimedOut: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := log.Events()
+	if timedOut, ok := events[len(events)-3].(DecisionTimedOut); !ok || timedOut.PlayerId != turn.PlayerId {
+		t.Errorf("expected a timed out action to be published but got:%+v", events[len(events)-3])
+	}
+	if called, ok := events[len(events)-2].(Called); !ok || called.Points != 0 {
+		t.Errorf("expected a timed out call to quit the game but got:%+v", events[len(events)-2])
+	}
+	if next.Kind != CallTurn || next.PlayerId == turn.PlayerId {
+		t.Errorf("expected another player to call after the first quit but got:%+v", next)
+	}
+}
+
+func TestApplyValidatesCalls(t *testing.T) {
+	players := getFourTestingPlayers()
+	g := NewGame(0, players, 1, 1, 0, 2, 10, nil, DefaultRules())
+	if err := g.Start(NewSeededDeck(5)); err != nil {
+		t.Fatal(err)
+	}
+	turn := g.NextAction()
+	caller := g.caller
+	caller.points = 5
+	if want := []int{0, 2, 4, 5}; !reflect.DeepEqual(g.NextAction().Options, want) {
+		t.Errorf("expected the options to replace calls the player can't afford by going all-in but got:%v", g.NextAction().Options)
+	}
+	other := players[0].id
+	if other == turn.PlayerId {
+		other = players[1].id
+	}
+	for _, tc := range []struct {
+		action Action
+		want   error
+	}{
+		{Action{PlayerId: other, Points: 2}, ErrNotYourTurn},
+		{Action{PlayerId: turn.PlayerId, Points: 3}, ErrInvalidCall},
+		{Action{PlayerId: turn.PlayerId, Points: 7}, ErrInvalidCall},
+		{Action{PlayerId: turn.PlayerId, Points: 20}, ErrInvalidCall},
+		{Action{PlayerId: turn.PlayerId, Points: 6}, ErrInsufficientPoints},
+	} {
+		next, err := g.Apply(tc.action)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%+v: expected %v but got:%v", tc.action, tc.want, err)
+		}
+		if next.Kind != CallTurn || next.PlayerId != turn.PlayerId || g.pot != 4 || caller.points != 5 {
+			t.Errorf("%+v: expected a rejected call to leave the game as it was but got:%+v", tc.action, next)
+		}
+	}
+	if _, err := g.Apply(Action{PlayerId: turn.PlayerId, Points: 4}); err != nil || g.pot != 8 {
+		t.Errorf("expected a valid call to be applied but got:%v with pot:%d", err, g.pot)
+	}
+}
+
+func TestCallerShortOfTheStepGoesAllIn(t *testing.T) {
+	players := getFourTestingPlayers()[:2]
+	g := NewGame(0, players, 1, 1, 0, 2, 10, nil, DefaultRules())
+	if err := g.Start(NewSeededDeck(5)); err != nil {
+		t.Fatal(err)
+	}
+	turn := g.NextAction()
+	g.caller.points = 1
+	if want := []int{0, 1}; !reflect.DeepEqual(g.NextAction().Options, want) {
+		t.Errorf("expected a caller with 1 point to be able to go all-in but got:%v", g.NextAction().Options)
+	}
+	if _, err := g.Apply(Action{PlayerId: turn.PlayerId, Points: 1}); err != nil {
+		t.Fatalf("expected an all-in call to be valid but got:%v", err)
+	}
+	if g.caller.points != 0 || !g.allIn[turn.PlayerId] || g.callingPoint != 1 {
+		t.Errorf("expected the caller to be all-in on a call of 1 but got points:%d, calling:%d", g.caller.points, g.callingPoint)
+	}
+}
+
+// a middle game calling off the ladder a few times before calling the step.
+type offLadderMiddleGame struct {
+	alwaysInMiddleGame
+	misses map[string]int // invalid calls left by player id.
+}
+
+func (m offLadderMiddleGame) CallOnce(view TableView, step, end int, lastCall bool) int {
+	if id := view.Self().Id; m.misses[id] > 0 {
+		m.misses[id]--
+		return step + 1
+	}
+	return step
+}
+
+func TestRunReasksInvalidCalls(t *testing.T) {
+	players := getFourTestingPlayers()
+	log := &EventLog{}
+	g := NewGame(0, players, 1, 1, 0, 2, 10, nil, DefaultRules())
+	g.SetEventSink(log)
+	md := offLadderMiddleGame{misses: map[string]int{"0": 1, "1": 1, "2": 1, "3": 1}}
+	if err := g.Start(NewSeededDeck(5)); err != nil {
+		t.Fatal(err)
+	}
+	caller := g.NextAction().PlayerId
+	md.misses[caller] = maxCallAttempts // the first calling player never gets it right in the first round.
+	for turn := g.NextAction(); turn.Kind != NoTurn; {
+		turn = g.decide(md, turn)
+	}
+
+	rejected, quit := 0, false
+	for _, e := range log.Events() {
+		switch ev := e.(type) {
+		case CallRejected:
+			rejected++
+			if !strings.Contains(ev.Reason, ErrInvalidCall.Error()) || ev.Points != 3 {
+				t.Errorf("expected a call of 3 to be rejected as invalid but got:%+v", ev)
+			}
+		case Called:
+			if ev.Points != 0 && ev.Points != 2 {
+				t.Errorf("expected only valid calls to be applied but got:%+v", ev)
+			}
+			if ev.PlayerId == caller && ev.Round == 1 {
+				quit = ev.Points == 0
+			}
+		}
+	}
+	if rejected < maxCallAttempts || !quit {
+		t.Errorf("expected the first calling player to quit after %d rejected calls but got %d rejected calls", maxCallAttempts, rejected)
+	}
+	if _, err := Replay(log.Events()); err != nil {
+		t.Errorf("expected a game with rejected calls to replay but got:%v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package douji
+
+// SeatView is what everyone at the table can see of a player.
+type SeatView struct {
+	Id     string
+	Name   string
+	Points int
+	In     bool // still in the current game.
+	AllIn  bool // put all their points into the pot, so no longer calls or is asked in or out.
+	public []Card
+	rules  *RuleSet
+}
+
+// PublicCards returns a copy of the player's public cards.
+func (s SeatView) PublicCards() []Card {
+	return append([]Card(nil), s.public...)
+}
+
+// PublicScore returns the total score of the player's public cards.
+func (s SeatView) PublicScore() int {
+	return s.PublicBreakdown().Total
+}
+
+// PublicBreakdown explains the player's public score.
+func (s SeatView) PublicBreakdown() ScoreBreakdown {
+	if s.rules == nil {
+		return defaultRules.Evaluate(s.public)
+	}
+	return s.rules.Evaluate(s.public)
+}
+
+// FaceScore returns the score of the player's last public card.
+func (s SeatView) FaceScore() int {
+	if len(s.public) == 0 {
+		return 0
+	}
+	return s.public[len(s.public)-1].rank
+}
+
+// CallRecord is a call made so far in the current game; 0 means the calling player quit.
+type CallRecord struct {
+	Round    int
+	PlayerId string
+	Points   int
+}
+
+// TableView is an immutable snapshot of a game as seen by one player: their own hidden cards and what's public to everyone.
+type TableView struct {
+	self     SeatView
+	hidden   []Card
+	seats    []SeatView
+	pot      int
+	round    int
+	maxRound int
+	step     int
+	end      int
+	calls    []CallRecord
+	rules    *RuleSet
+}
+
+// Self returns the player the view belongs to.
+func (v TableView) Self() SeatView {
+	return v.self
+}
+
+// HiddenCards returns a copy of the player's own hidden cards.
+func (v TableView) HiddenCards() []Card {
+	return append([]Card(nil), v.hidden...)
+}
+
+// Score returns the player's own final score if the game ended now, i.e. of both hidden and public cards.
+func (v TableView) Score() int {
+	return v.Breakdown().Total
+}
+
+// Breakdown explains the player's own final score if the game ended now.
+func (v TableView) Breakdown() ScoreBreakdown {
+	return v.Rules().Evaluate(append(v.HiddenCards(), v.self.public...))
+}
+
+// Seats returns every player who started the game in seating order, including the player itself.
+func (v TableView) Seats() []SeatView {
+	return append([]SeatView(nil), v.seats...)
+}
+
+// Opponents returns the other players still in the game.
+func (v TableView) Opponents() []SeatView {
+	var ops []SeatView
+	for _, s := range v.seats {
+		if s.In && s.Id != v.self.Id {
+			ops = append(ops, s)
+		}
+	}
+	return ops
+}
+
+// Pot returns the points in the pot.
+func (v TableView) Pot() int {
+	return v.pot
+}
+
+// Round returns the current round, starting from 1.
+func (v TableView) Round() int {
+	return v.round
+}
+
+// MaxRound returns the final round of the game.
+func (v TableView) MaxRound() int {
+	return v.maxRound
+}
+
+// Step returns the current calling step.
+func (v TableView) Step() int {
+	return v.step
+}
+
+// End returns the largest call before the final round.
+func (v TableView) End() int {
+	return v.end
+}
+
+// CallOptions returns the calls the player can choose from if they call the current round, 0 meaning quitting the game.
+// Calls of more points than the player has are left out, going all-in with every point they have takes their place.
+func (v TableView) CallOptions() []int {
+	return affordable(v.Rules().callLadder(v.step, v.end, v.round == v.maxRound), v.self.Points)
+}
+
+// Rules returns the rules of the game.
+func (v TableView) Rules() RuleSet {
+	if v.rules == nil {
+		return defaultRules
+	}
+	return *v.rules
+}
+
+// Calls returns every call made so far in the game.
+func (v TableView) Calls() []CallRecord {
+	return append([]CallRecord(nil), v.calls...)
+}
+
+// view creates a snapshot of the game as seen by p.
+func (g *Game) view(p *Player) TableView {
+	in := make(map[string]bool, len(g.players))
+	for _, player := range g.players {
+		in[player.id] = true
+	}
+	seats := make([]SeatView, len(g.seated))
+	rules := g.rules.orDefault()
+	var self SeatView
+	for i, player := range g.seated {
+		seats[i] = SeatView{
+			Id:     player.id,
+			Name:   player.Name,
+			Points: player.points,
+			In:     in[player.id],
+			AllIn:  g.allIn[player.id],
+			public: append([]Card(nil), player.publicCards...),
+			rules:  &rules,
+		}
+		if player.id == p.id {
+			self = seats[i]
+		}
+	}
+	return TableView{
+		self:     self,
+		hidden:   append([]Card(nil), p.privateCards...),
+		seats:    seats,
+		pot:      g.pot,
+		round:    g.round,
+		maxRound: g.maxRound,
+		step:     g.step,
+		end:      g.end,
+		calls:    append([]CallRecord(nil), g.calls...),
+		rules:    &rules,
+	}
+}
@@ -0,0 +1,90 @@
+package douji
+
+import (
+	"testing"
+)
+
+// a middle game recording every view it's given.
+type viewRecorder struct {
+	alwaysInMiddleGame
+	views []TableView
+}
+
+func (r *viewRecorder) CallOnce(view TableView, step, end int, lastCall bool) int {
+	r.views = append(r.views, view)
+	return step
+}
+
+func (r *viewRecorder) InOrOut(view TableView, callingChip int) bool {
+	r.views = append(r.views, view)
+	return view.Self().Id != "2" // Gu always goes out.
+}
+
+func TestTableView(t *testing.T) {
+	players := getFourTestingPlayers()
+	game := NewGame(0, players, 1, 2, 0, 1, 5, nil, DefaultRules())
+	md := &viewRecorder{}
+	game.run(false, md, NewSeededDeck(5))
+
+	for _, v := range md.views {
+		var owner *Player
+		for _, p := range players {
+			if p.id == v.Self().Id {
+				owner = p
+			}
+		}
+		hidden := v.HiddenCards()
+		if len(hidden) != 2 || !isCard(hidden[0], owner.privateCards[0]) || !isCard(hidden[1], owner.privateCards[1]) {
+			t.Errorf("expected %s to see exactly its own hidden cards but got:%v", owner.Name, hidden)
+		}
+		if len(v.Seats()) != 4 {
+			t.Errorf("expected every seated player in the view but got:%d", len(v.Seats()))
+		}
+		for _, op := range v.Opponents() {
+			if op.Id == v.Self().Id || !op.In {
+				t.Errorf("expected opponents to be other players still in the game but got:%+v", op)
+			}
+		}
+		if v.Round() < 1 || v.Round() > v.MaxRound() {
+			t.Errorf("expected a round within the game but got:%d", v.Round())
+		}
+	}
+
+	first := md.views[0]
+	if first.Pot() != 4 || len(first.Calls()) != 0 {
+		t.Errorf("expected the first caller to see a pot of 4 bases and no calls but got pot:%d, calls:%v", first.Pot(), first.Calls())
+	}
+	second := md.views[1]
+	if second.Pot() != 5 || len(second.Calls()) != 1 || second.Calls()[0].PlayerId != "0" {
+		t.Errorf("expected the first asked player to see Liu's call in the pot but got pot:%d, calls:%v", second.Pot(), second.Calls())
+	}
+	last := md.views[len(md.views)-1]
+	for _, s := range last.Seats() {
+		if s.Id == "2" && s.In {
+			t.Errorf("expected Gu to be out of the game in the last view.")
+		}
+	}
+}
+
+func TestTableViewIsImmutable(t *testing.T) {
+	p := &Player{id: "1", Name: "Liu", points: 10, Hand: Hand{privateCards: []Card{{rank: 5}}, publicCards: []Card{{rank: 7}}}}
+	g := &Game{seated: []*Player{p}, players: []*Player{p}, pot: 3, round: 1, maxRound: 4, step: 1, end: 5}
+	v := g.view(p)
+
+	v.HiddenCards()[0] = Card{rank: 21}
+	v.Self().PublicCards()[0] = Card{rank: 21}
+	v.Seats()[0].Points = 1000
+	p.ReceivePublicCard(Card{rank: 9})
+	p.points = 0
+	g.pot = 100
+
+	if v.HiddenCards()[0].rank != 5 || len(v.Self().PublicCards()) != 1 || v.Self().PublicCards()[0].rank != 7 {
+		t.Errorf("expected a view's cards to never change.")
+	}
+	if v.Self().Points != 10 || v.Seats()[0].Points != 10 || v.Pot() != 3 {
+		t.Errorf("expected a view's points and pot to never change.")
+	}
+	if v.Score() != 12 || v.Self().PublicScore() != 7 || v.Self().FaceScore() != 7 {
+		t.Errorf("expected view scores 12, 7 and 7 but got:%d, %d, %d", v.Score(), v.Self().PublicScore(), v.Self().FaceScore())
+	}
+}